@@ -15,13 +15,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"k8s.io/klog/v2"
 
+	"github.com/zxh326/kite/pkg/audit"
 	"github.com/zxh326/kite/pkg/auth"
 	"github.com/zxh326/kite/pkg/cluster"
 	"github.com/zxh326/kite/pkg/common"
 	"github.com/zxh326/kite/pkg/handlers"
 	"github.com/zxh326/kite/pkg/handlers/resources"
 	"github.com/zxh326/kite/pkg/middleware"
+	"github.com/zxh326/kite/pkg/rbac"
+	"github.com/zxh326/kite/pkg/recording"
 	"github.com/zxh326/kite/pkg/utils"
+	"github.com/zxh326/kite/pkg/webhook"
 
 	_ "net/http/pprof"
 )
@@ -75,7 +79,7 @@ func setupAPIRouter(r *gin.Engine, cm *cluster.ClusterManager) {
 	r.GET("/api/v1/version", versionHandler.GetVersionInfo)
 	r.GET("/api/v1/version/current", versionHandler.GetCurrentVersion)
 	// Upgrade endpoint (requires auth)
-	r.POST("/api/v1/version/upgrade", authHandler.RequireAuth(), middleware.ClusterMiddleware(cm), versionHandler.UpgradeKite)
+	r.POST("/api/v1/version/upgrade", authHandler.RequireAuth(), middleware.ClusterMiddleware(cm), middleware.AuditMiddleware(), middleware.RBACMiddleware(), versionHandler.UpgradeKite)
 
 	// Auth routes (no auth required)
 	authGroup := r.Group("/api/auth")
@@ -91,10 +95,25 @@ func setupAPIRouter(r *gin.Engine, cm *cluster.ClusterManager) {
 
 	// API routes group (protected)
 	api := r.Group("/api/v1")
-	api.Use(authHandler.RequireAuth(), middleware.ClusterMiddleware(cm), middleware.ReadonlyMiddleware())
+	api.Use(authHandler.RequireAuth(), middleware.ClusterMiddleware(cm), middleware.AuditMiddleware(), middleware.RBACMiddleware(), middleware.ReadonlyMiddleware())
 	{
 		api.GET("/overview", handlers.GetOverview)
 		api.GET("/clusters", cm.GetClusters)
+		api.POST("/clusters", cm.CreateCluster)
+		api.POST("/clusters/test", cm.TestClusterConnection)
+		api.PUT("/clusters/:name", cm.UpdateClusterCredentials)
+		api.DELETE("/clusters/:name", cm.DeleteCluster)
+		api.POST("/clusters/:name/health", cm.CheckClusterHealth)
+		api.GET("/permissions", handlers.GetPermissions)
+		api.GET("/audit", handlers.GetAuditLogs)
+		api.GET("/recordings", handlers.ListRecordings)
+		api.GET("/recordings/:id", handlers.GetRecording)
+		api.GET("/recordings/:id/cast", handlers.GetRecordingCast)
+		api.DELETE("/recordings/:id", handlers.DeleteRecording)
+		api.GET("/webhook-tokens", handlers.ListWebhookTokens)
+		api.POST("/webhook-tokens", handlers.CreateWebhookToken)
+		api.POST("/webhook-tokens/:name/rotate", handlers.RotateWebhookToken)
+		api.DELETE("/webhook-tokens/:name", handlers.RevokeWebhookToken)
 
 		promHandler := handlers.NewPromHandler()
 		api.GET("/prometheus/resource-usage-history", promHandler.GetResourceUsageHistory)
@@ -102,6 +121,10 @@ func setupAPIRouter(r *gin.Engine, cm *cluster.ClusterManager) {
 
 		logsHandler := handlers.NewLogsHandler()
 		api.GET("/logs/:namespace/:podName", logsHandler.GetPodLogs)
+		api.GET("/logs/:namespace/:podName/download", logsHandler.DownloadPodLogs)
+		api.GET("/logs/:namespace/:podName/bundle", logsHandler.DownloadPodLogsBundle)
+		api.GET("/workload-logs/:resource/:namespace/:name", logsHandler.GetWorkloadLogs)
+		api.GET("/workload-logs/:resource/:namespace/:name/bundle", logsHandler.DownloadWorkloadLogsBundle)
 
 		terminalHandler := handlers.NewTerminalHandler()
 		api.GET("/terminal/:namespace/:podName/ws", terminalHandler.HandleTerminalWebSocket)
@@ -112,6 +135,11 @@ func setupAPIRouter(r *gin.Engine, cm *cluster.ClusterManager) {
 		searchHandler := handlers.NewSearchHandler()
 		api.GET("/search", searchHandler.GlobalSearch)
 
+		multiClusterHandler := handlers.NewMultiClusterHandler(cm)
+		api.GET("/multicluster/search", multiClusterHandler.Search)
+		api.GET("/multicluster/:resource", multiClusterHandler.List)
+		api.GET("/multicluster/:resource/:namespace", multiClusterHandler.List)
+
 		resourceApplyHandler := handlers.NewResourceApplyHandler()
 		api.POST("/resources/apply", resourceApplyHandler.ApplyResource)
 
@@ -120,12 +148,11 @@ func setupAPIRouter(r *gin.Engine, cm *cluster.ClusterManager) {
 }
 
 func setupWebhookRouter(r *gin.Engine, cm *cluster.ClusterManager) {
-	webhookGroup := r.Group("/api/v1/webhooks", gin.BasicAuth(gin.Accounts{
-		common.WebhookUsername: common.WebhookPassword,
-	}), middleware.ClusterMiddleware(cm))
+	webhookGroup := r.Group("/api/v1/webhooks", middleware.WebhookAuth(), middleware.ClusterMiddleware(cm), middleware.AuditMiddleware())
 	{
 		webhookHandler := handlers.NewWebhookHandler(cm)
 		webhookGroup.POST("/events", webhookHandler.HandleWebhook)
+		webhookGroup.POST("/registry/:format", webhookHandler.HandleRegistryWebhook)
 	}
 }
 
@@ -137,6 +164,30 @@ func main() {
 	}()
 
 	common.LoadEnvs()
+	if common.RBACConfigPath != "" {
+		if err := rbac.Load(common.RBACConfigPath); err != nil {
+			log.Fatalf("Failed to load RBAC config: %v", err)
+		}
+	}
+	if err := audit.Init(common.AuditSink, common.AuditPath); err != nil {
+		log.Fatalf("Failed to initialize audit sink: %v", err)
+	}
+	if err := webhook.Init(common.WebhookTokenStore, common.WebhookTokenPath); err != nil {
+		log.Fatalf("Failed to initialize webhook token store: %v", err)
+	}
+	if common.WebhookDefaultPassword {
+		if !webhook.Persistent() {
+			klog.Warning("WEBHOOK_PASSWORD is not set and webhook tokens are kept in memory, the default webhook password is disabled")
+		} else if !webhook.Exists() {
+			klog.Warningf("WEBHOOK_PASSWORD is not set, webhooks accept the default credentials %s:%s until a webhook token is created", common.WebhookUsername, common.WebhookPassword)
+		}
+	}
+	if err := recording.Init(common.RecordingStore, common.RecordingPath, recording.Retention{
+		MaxAge:   common.RecordingRetention,
+		MaxCount: common.RecordingMaxCount,
+	}); err != nil {
+		log.Fatalf("Failed to initialize recording store: %v", err)
+	}
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 	r.Use(gin.Recovery())
@@ -174,4 +225,10 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		klog.Fatalf("Failed to shutdown server: %v", err)
 	}
+	if err := audit.Close(); err != nil {
+		klog.Errorf("Failed to close audit sink: %v", err)
+	}
+	if err := recording.Close(); err != nil {
+		klog.Errorf("Failed to close recording store: %v", err)
+	}
 }
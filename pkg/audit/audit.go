@@ -0,0 +1,230 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Entry is a single audited action
+type Entry struct {
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`
+	Provider   string    `json:"provider,omitempty"`
+	ClientIP   string    `json:"clientIP,omitempty"`
+	Cluster    string    `json:"cluster"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Resource   string    `json:"resource"`
+	Name       string    `json:"name,omitempty"`
+	Verb       string    `json:"verb"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"statusCode"`
+	Error      string    `json:"error,omitempty"`
+	// Request holds the body of operations like scale, never full manifests
+	Request string `json:"request,omitempty"`
+	// Diff is a unified diff of the object YAML before and after the change
+	Diff       string `json:"diff,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Filter selects entries in Query, zero values match everything
+type Filter struct {
+	User      string
+	Cluster   string
+	Namespace string
+	Resource  string
+	Name      string
+	Verb      string
+	Success   *bool
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+func (f *Filter) Match(e *Entry) bool {
+	switch {
+	case f.User != "" && e.User != f.User,
+		f.Cluster != "" && e.Cluster != f.Cluster,
+		f.Namespace != "" && e.Namespace != f.Namespace,
+		f.Resource != "" && e.Resource != f.Resource,
+		f.Name != "" && e.Name != f.Name,
+		f.Verb != "" && e.Verb != f.Verb,
+		f.Success != nil && e.Success != *f.Success,
+		!f.Since.IsZero() && e.Time.Before(f.Since),
+		!f.Until.IsZero() && e.Time.After(f.Until):
+		return false
+	}
+	return true
+}
+
+// Sink persists audit entries
+type Sink interface {
+	Write(entry *Entry) error
+	// Query returns matching entries, newest first
+	Query(filter Filter) ([]Entry, error)
+	Close() error
+}
+
+var sink Sink = NewMemorySink(nil)
+
+// Init selects the sink: memory (default), stdout, file or sqlite
+func Init(kind, path string) error {
+	var (
+		s   Sink
+		err error
+	)
+	switch kind {
+	case "", "memory":
+		s = NewMemorySink(nil)
+	case "stdout":
+		s = NewStdoutSink()
+	case "file":
+		if path == "" {
+			path = "kite-audit.log"
+		}
+		s, err = NewFileSink(path)
+	case "sqlite":
+		if path == "" {
+			path = "kite-audit.db"
+		}
+		s, err = NewSQLiteSink(path)
+	default:
+		return fmt.Errorf("unknown audit sink %q", kind)
+	}
+	if err != nil {
+		return err
+	}
+	_ = sink.Close()
+	sink = s
+	return nil
+}
+
+// Write records an entry in the configured sink
+func Write(entry *Entry) {
+	if err := sink.Write(entry); err != nil {
+		klog.Errorf("Failed to write audit entry %s %s/%s: %v", entry.Verb, entry.Resource, entry.Name, err)
+	}
+}
+
+// Query reads entries from the configured sink
+func Query(filter Filter) ([]Entry, error) {
+	return sink.Query(filter)
+}
+
+// Close flushes and closes the configured sink
+func Close() error {
+	return sink.Close()
+}
+
+// contextKey stores the in-flight entry in the gin context
+const contextKey = "audit-entry"
+
+// FromContext returns the entry of the current request, if it is audited
+func FromContext(c *gin.Context) (*Entry, bool) {
+	v, ok := c.Get(contextKey)
+	if !ok {
+		return nil, false
+	}
+	entry, ok := v.(*Entry)
+	return entry, ok
+}
+
+// NewContext attaches an entry to the request so handlers can enrich it
+func NewContext(c *gin.Context, entry *Entry) {
+	c.Set(contextKey, entry)
+}
+
+// SetTarget overrides the resource targeted by the current request
+func SetTarget(c *gin.Context, resource, namespace, name string) {
+	if entry, ok := FromContext(c); ok {
+		entry.Resource = resource
+		entry.Namespace = namespace
+		entry.Name = name
+	}
+}
+
+// RecordChange appends the diff between two versions of an object to the
+// current request entry. Either side may be nil for creations and deletions.
+func RecordChange(c *gin.Context, before, after any) {
+	entry, ok := FromContext(c)
+	if !ok {
+		return
+	}
+	diff, err := Diff(before, after)
+	if err != nil {
+		klog.Warningf("Failed to diff audited object: %v", err)
+		return
+	}
+	entry.Diff += diff
+}
+
+// Diff returns a unified diff of the YAML of two objects. Secret values are
+// replaced by a hash so changes stay visible without leaking them.
+func Diff(before, after any) (string, error) {
+	from, err := toYAML(before)
+	if err != nil {
+		return "", err
+	}
+	to, err := toYAML(after)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: "before",
+		ToFile:   "after",
+		Context:  3,
+	})
+}
+
+func toYAML(obj any) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return "", err
+	}
+	if m == nil {
+		return "", nil
+	}
+	if metadata, ok := m["metadata"].(map[string]any); ok {
+		delete(metadata, "managedFields")
+	}
+	_, isSecret := obj.(*corev1.Secret)
+	if isSecret || m["kind"] == "Secret" {
+		redact(m, "data")
+		redact(m, "stringData")
+	}
+	out, err := yaml.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func redact(m map[string]any, field string) {
+	values, ok := m[field].(map[string]any)
+	if !ok {
+		return
+	}
+	for key, value := range values {
+		sum := sha256.Sum256(fmt.Append(nil, value))
+		values[key] = "<redacted sha256:" + hex.EncodeToString(sum[:])[:12] + ">"
+	}
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffRedactsSecrets(t *testing.T) {
+	before := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	after := before.DeepCopy()
+	after.Data["password"] = []byte("correct-horse")
+
+	diff, err := Diff(before, after)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-  password: <redacted sha256:")
+	assert.Contains(t, diff, "+  password: <redacted sha256:")
+	assert.NotContains(t, diff, "hunter2")
+	assert.NotContains(t, diff, "Y29ycmVjdC1ob3JzZQ==")
+}
+
+func testSinkQuery(t *testing.T, sink Sink) {
+	now := time.Now()
+	entries := []Entry{
+		{ID: "1", Time: now.Add(-2 * time.Minute), User: "alice", Cluster: "prod", Namespace: "default", Resource: "deployments", Name: "web", Verb: "restart", Success: true},
+		{ID: "2", Time: now.Add(-time.Minute), User: "bob", Cluster: "prod", Namespace: "default", Resource: "pods", Name: "web-1", Verb: "delete", Success: false, Error: "forbidden"},
+		{ID: "3", Time: now, User: "alice", Cluster: "staging", Resource: "nodes", Name: "node-1", Verb: "cordon", Success: true},
+	}
+	for i := range entries {
+		require.NoError(t, sink.Write(&entries[i]))
+	}
+
+	result, err := sink.Query(Filter{User: "alice"})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "3", result[0].ID, "newest entries come first")
+
+	success := false
+	result, err = sink.Query(Filter{Cluster: "prod", Success: &success})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "forbidden", result[0].Error)
+
+	result, err = sink.Query(Filter{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "3", result[0].ID)
+}
+
+func TestSinks(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("memory", func(t *testing.T) {
+		testSinkQuery(t, NewMemorySink(nil))
+	})
+	t.Run("file", func(t *testing.T) {
+		sink, err := NewFileSink(filepath.Join(dir, "audit.log"))
+		require.NoError(t, err)
+		defer func() { _ = sink.Close() }()
+		testSinkQuery(t, sink)
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		sink, err := NewSQLiteSink(filepath.Join(dir, "audit.db"))
+		require.NoError(t, err)
+		defer func() { _ = sink.Close() }()
+		testSinkQuery(t, sink)
+	})
+}
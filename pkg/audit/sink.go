@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxMemoryEntries bounds the entries kept by memory and stdout sinks
+const maxMemoryEntries = 1000
+
+// MemorySink keeps the latest entries in memory and optionally writes them
+// as JSON lines, which is how the stdout sink works
+type MemorySink struct {
+	mu      sync.RWMutex
+	entries []Entry
+	out     io.Writer
+}
+
+func NewMemorySink(out io.Writer) *MemorySink {
+	return &MemorySink{out: out}
+}
+
+// NewStdoutSink writes entries to stdout as JSON lines
+func NewStdoutSink() *MemorySink {
+	return NewMemorySink(os.Stdout)
+}
+
+func (s *MemorySink) Write(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, *entry)
+	if len(s.entries) > maxMemoryEntries {
+		s.entries = s.entries[len(s.entries)-maxMemoryEntries:]
+	}
+	if s.out == nil {
+		return nil
+	}
+	return json.NewEncoder(s.out).Encode(entry)
+}
+
+func (s *MemorySink) Query(filter Filter) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]Entry, 0)
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+		if filter.Match(&s.entries[i]) {
+			result = append(result, s.entries[i])
+		}
+	}
+	return result, nil
+}
+
+func (s *MemorySink) Close() error {
+	return nil
+}
+
+// FileSink appends entries as JSON lines to a local file
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{path: path, file: file}, nil
+}
+
+func (s *FileSink) Write(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+// Query scans the whole file, it is meant for modest audit volumes
+func (s *FileSink) Query(filter Filter) ([]Entry, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	matched := make([]Entry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.Match(&entry) {
+			matched = append(matched, entry)
+			if filter.Limit > 0 && len(matched) > filter.Limit {
+				matched = matched[1:]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Newest first
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched, nil
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
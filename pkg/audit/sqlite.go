@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS audit_logs (
+	id          TEXT PRIMARY KEY,
+	time        INTEGER NOT NULL,
+	user        TEXT NOT NULL,
+	provider    TEXT,
+	client_ip   TEXT,
+	cluster     TEXT NOT NULL,
+	namespace   TEXT,
+	resource    TEXT NOT NULL,
+	name        TEXT,
+	verb        TEXT NOT NULL,
+	success     INTEGER NOT NULL,
+	status_code INTEGER NOT NULL,
+	error       TEXT,
+	request     TEXT,
+	diff        TEXT,
+	duration_ms INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_logs_time ON audit_logs (time);
+CREATE INDEX IF NOT EXISTS audit_logs_cluster ON audit_logs (cluster, namespace, resource);
+`
+
+// SQLiteSink stores entries in a SQLite database
+type SQLiteSink struct {
+	db *sql.DB
+}
+
+func NewSQLiteSink(path string) (*SQLiteSink, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &SQLiteSink{db: db}, nil
+}
+
+func (s *SQLiteSink) Write(entry *Entry) error {
+	_, err := s.db.Exec(`INSERT INTO audit_logs
+		(id, time, user, provider, client_ip, cluster, namespace, resource, name, verb, success, status_code, error, request, diff, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.Time.UnixNano(), entry.User, entry.Provider, entry.ClientIP, entry.Cluster, entry.Namespace,
+		entry.Resource, entry.Name, entry.Verb, entry.Success, entry.StatusCode, entry.Error, entry.Request, entry.Diff, entry.DurationMs)
+	return err
+}
+
+func (s *SQLiteSink) Query(filter Filter) ([]Entry, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	addCondition := func(column string, value any) {
+		conditions = append(conditions, column+" = ?")
+		args = append(args, value)
+	}
+	if filter.User != "" {
+		addCondition("user", filter.User)
+	}
+	if filter.Cluster != "" {
+		addCondition("cluster", filter.Cluster)
+	}
+	if filter.Namespace != "" {
+		addCondition("namespace", filter.Namespace)
+	}
+	if filter.Resource != "" {
+		addCondition("resource", filter.Resource)
+	}
+	if filter.Name != "" {
+		addCondition("name", filter.Name)
+	}
+	if filter.Verb != "" {
+		addCondition("verb", filter.Verb)
+	}
+	if filter.Success != nil {
+		addCondition("success", *filter.Success)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "time >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "time <= ?")
+		args = append(args, filter.Until.UnixNano())
+	}
+
+	query := `SELECT id, time, user, provider, client_ip, cluster, namespace, resource, name, verb, success,
+		status_code, error, request, diff, duration_ms FROM audit_logs`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY time DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var (
+			entry    Entry
+			nanos    int64
+			nullable [7]sql.NullString
+		)
+		if err := rows.Scan(&entry.ID, &nanos, &entry.User, &nullable[0], &nullable[1], &entry.Cluster, &nullable[2],
+			&entry.Resource, &nullable[3], &entry.Verb, &entry.Success, &entry.StatusCode, &nullable[4], &nullable[5],
+			&nullable[6], &entry.DurationMs); err != nil {
+			return nil, err
+		}
+		entry.Time = time.Unix(0, nanos)
+		entry.Provider = nullable[0].String
+		entry.ClientIP = nullable[1].String
+		entry.Namespace = nullable[2].String
+		entry.Name = nullable[3].String
+		entry.Error = nullable[4].String
+		entry.Request = nullable[5].String
+		entry.Diff = nullable[6].String
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
@@ -266,6 +266,7 @@ func (h *AuthHandler) RequireAuth() gin.HandlerFunc {
 			"name":       claims.Name,
 			"avatar_url": claims.AvatarURL,
 			"provider":   claims.Provider,
+			"groups":     claims.Groups,
 		})
 		c.Next()
 	}
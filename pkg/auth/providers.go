@@ -14,6 +14,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/rbac"
 )
 
 // OAuthProvider defines the interface for OAuth providers
@@ -35,11 +36,12 @@ type OAuthConfig struct {
 
 // User represents a generic user from any OAuth provider
 type User struct {
-	ID        string `json:"id"`
-	Username  string `json:"username"`
-	Name      string `json:"name"`
-	AvatarURL string `json:"avatar_url"`
-	Provider  string `json:"provider"`
+	ID        string   `json:"id"`
+	Username  string   `json:"username"`
+	Name      string   `json:"name"`
+	AvatarURL string   `json:"avatar_url"`
+	Provider  string   `json:"provider"`
+	Groups    []string `json:"groups,omitempty"`
 }
 
 // TokenResponse represents OAuth token response with refresh token support
@@ -53,12 +55,13 @@ type TokenResponse struct {
 
 // Claims represents JWT claims with refresh token support
 type Claims struct {
-	UserID       string `json:"user_id"`
-	Username     string `json:"username"`
-	Name         string `json:"name"`
-	AvatarURL    string `json:"avatar_url"`
-	Provider     string `json:"provider"`
-	RefreshToken string `json:"refresh_token,omitempty"`
+	UserID       string   `json:"user_id"`
+	Username     string   `json:"username"`
+	Name         string   `json:"name"`
+	AvatarURL    string   `json:"avatar_url"`
+	Provider     string   `json:"provider"`
+	Groups       []string `json:"groups,omitempty"`
+	RefreshToken string   `json:"refresh_token,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -325,6 +328,11 @@ func (g *GenericProvider) GetUserInfo(accessToken string) (*User, error) {
 	} else if picture, ok := userInfo["picture"]; ok {
 		user.AvatarURL = fmt.Sprintf("%v", picture)
 	}
+	if groups, ok := userInfo["groups"].([]interface{}); ok {
+		for _, group := range groups {
+			user.Groups = append(user.Groups, fmt.Sprintf("%v", group))
+		}
+	}
 
 	return user, nil
 }
@@ -392,6 +400,7 @@ func (om *OAuthManager) GenerateJWT(user *User, refreshToken string) (string, er
 		Name:         user.Name,
 		AvatarURL:    user.AvatarURL,
 		Provider:     user.Provider,
+		Groups:       user.Groups,
 		RefreshToken: refreshToken,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
@@ -471,12 +480,16 @@ func (om *OAuthManager) RefreshJWT(tokenString string) (string, error) {
 		Name:      claims.Name,
 		AvatarURL: claims.AvatarURL,
 		Provider:  claims.Provider,
+		Groups:    claims.Groups,
 	}
 
 	return om.GenerateJWT(user, "")
 }
 
 func CheckPermissions(user *User) bool {
+	if rbac.Enabled() && rbac.Current().HasAnyBinding(rbac.Subject{Username: user.Username, Groups: user.Groups}) {
+		return true
+	}
 	allowUsers := common.OAuthAllowUsers
 	if allowUsers == "" {
 		return false
@@ -3,15 +3,21 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/util/homedir"
 	"k8s.io/klog/v2"
 
@@ -26,11 +32,118 @@ type ClientSet struct {
 	Version    string // Kubernetes version
 	K8sClient  *kube.K8sClient
 	PromClient *prometheus.Client
+
+	// impersonated caches the per-user clients built by ForUser
+	impersonatedMu sync.Mutex
+	impersonated   *expirable.LRU[string, *kube.K8sClient]
+
+	// health is refreshed by the periodic checks of the ClusterManager
+	healthMu sync.RWMutex
+	health   common.ClusterHealth
+}
+
+// ForUser returns a copy of the ClientSet whose K8sClient impersonates the
+// given user and groups. Clients are cached per identity for a short time.
+func (cs *ClientSet) ForUser(username string, groups []string) (*ClientSet, error) {
+	sortedGroups := append([]string(nil), groups...)
+	sort.Strings(sortedGroups)
+	key := username + "|" + strings.Join(sortedGroups, ",")
+
+	cs.impersonatedMu.Lock()
+	defer cs.impersonatedMu.Unlock()
+	if cs.impersonated == nil {
+		cs.impersonated = expirable.NewLRU[string, *kube.K8sClient](256, nil, 10*time.Minute)
+	}
+	k8sClient, ok := cs.impersonated.Get(key)
+	if !ok {
+		var err error
+		k8sClient, err = cs.K8sClient.Impersonate(username, sortedGroups)
+		if err != nil {
+			return nil, err
+		}
+		cs.impersonated.Add(key, k8sClient)
+	}
+
+	return &ClientSet{
+		Name:       cs.Name,
+		Version:    cs.Version,
+		K8sClient:  k8sClient,
+		PromClient: cs.PromClient,
+	}, nil
 }
 
 type ClusterManager struct {
+	mu             sync.RWMutex
 	clusters       map[string]*ClientSet
 	defaultContext string
+
+	// registered tracks clusters added at runtime, they are persisted in store
+	registered map[string]bool
+	store      CredentialStore
+	// registerMu serializes runtime registration changes and kubeconfig reloads
+	registerMu sync.Mutex
+
+	// kubeconfig is the watched file, fingerprints hold the loaded contexts
+	kubeconfig   string
+	fingerprints map[string]string
+}
+
+// newClientSet builds the Kubernetes and Prometheus clients of a cluster. An
+// empty prometheusURL falls back to environment variables and auto-discovery.
+// It doesn't wait for the informer cache, so slow clusters don't delay startup
+// and unreachable ones are still registered and reported by health checks.
+func newClientSet(name string, restConfig *rest.Config, prometheusURL string, cacheKinds []string) (*ClientSet, error) {
+	k8sClient, err := kube.NewClient(restConfig, cacheOptions(name, cacheKinds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	if prometheusURL == "" {
+		prometheusURL = getPrometheusURL(name, k8sClient)
+	}
+	promClient, err := prometheus.NewClient(prometheusURL)
+	if err != nil {
+		klog.Warningf("Failed to create Prometheus client for cluster %s, some features may not work as expected, err: %v", name, err)
+	}
+	version, err := serverVersion(restConfig)
+	if err != nil {
+		klog.Warningf("Failed to get server version for cluster %s: %v", name, err)
+	}
+	return &ClientSet{
+		Name:       name,
+		Version:    version,
+		K8sClient:  k8sClient,
+		PromClient: promClient,
+	}, nil
+}
+
+// serverVersion asks the API server for its version, bounded by a timeout
+func serverVersion(restConfig *rest.Config) (string, error) {
+	restConfig = rest.CopyConfig(restConfig)
+	restConfig.Timeout = testTimeout
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return "", err
+	}
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return info.String(), nil
+}
+
+// cacheOptions reads the cache settings of a cluster, <CLUSTER>_CACHE_KINDS
+// takes precedence over the kinds of a registered cluster and CACHE_KINDS
+func cacheOptions(clusterName string, kinds []string) kube.CacheOptions {
+	opts := kube.CacheOptions{
+		Lazy:  os.Getenv("CACHE_LAZY") == "true",
+		Kinds: kinds,
+	}
+	if env := os.Getenv(utils.ToEnvName(clusterName) + "_CACHE_KINDS"); env != "" {
+		opts.Kinds = strings.Split(env, ",")
+	} else if env := os.Getenv("CACHE_KINDS"); env != "" && len(opts.Kinds) == 0 {
+		opts.Kinds = strings.Split(env, ",")
+	}
+	return opts
 }
 
 func createCmInCluster() (*ClusterManager, error) {
@@ -39,38 +152,28 @@ func createCmInCluster() (*ClusterManager, error) {
 		return nil, err
 	}
 
-	k8sClient, err := kube.NewClient(config)
+	cs, err := newClientSet("default", config, "", nil)
 	if err != nil {
 		return nil, err
 	}
-	promClient, err := prometheus.NewClient(getPrometheusURL("default", k8sClient))
-	if err != nil {
-		klog.Warningf("Failed to create Prometheus client, some features may not work as expected, err: %v", err)
-	}
+	cs.Name = "in-cluster"
 	klog.Infof("Loaded in-cluster K8s client")
 	return &ClusterManager{
 		clusters: map[string]*ClientSet{
-			"default": {
-				Name:       "in-cluster",
-				K8sClient:  k8sClient,
-				PromClient: promClient,
-			},
+			"default": cs,
 		},
 		defaultContext: "default",
 	}, nil
 }
 
-func createCmFromKubeconfig(kubeconfig string) (*ClusterManager, error) {
-	config, err := clientcmd.LoadFromFile(kubeconfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
-	}
-
+// buildContexts creates the clients of the given kubeconfig contexts
+// concurrently, contexts that fail are logged and left out
+func buildContexts(config *clientcmdapi.Config, contextNames []string) map[string]*ClientSet {
 	clusters := make(map[string]*ClientSet)
 	wg := sync.WaitGroup{}
-	wg.Add(len(config.Contexts))
+	wg.Add(len(contextNames))
 	mux := &sync.Mutex{}
-	for contextName := range config.Contexts {
+	for _, contextName := range contextNames {
 		go func(contextName string) {
 			defer wg.Done()
 			restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{
@@ -80,39 +183,45 @@ func createCmFromKubeconfig(kubeconfig string) (*ClusterManager, error) {
 				klog.Warningf("Failed to create config for context %s: %v", contextName, err)
 				return
 			}
-			k8sClient, err := kube.NewClient(restConfig)
+			cs, err := newClientSet(contextName, restConfig, "", nil)
 			if err != nil {
-				klog.Warningf("Failed to create k8s client for context %s: %v", contextName, err)
+				klog.Warningf("Failed to create clients for context %s: %v", contextName, err)
 				return
 			}
-			promClient, err := prometheus.NewClient(getPrometheusURL(contextName, k8sClient))
-			if err != nil {
-				klog.Warningf("Failed to create Prometheus client for cluster %s, some features may not work as expected, err: %v", contextName, err)
-			}
 			klog.Infof("Loaded K8s client for context: %s", contextName)
-			version := ""
-			v, err := k8sClient.ClientSet.Discovery().ServerVersion()
-			if err != nil {
-				klog.Warningf("Failed to get server version for context %s: %v", contextName, err)
-			} else {
-				version = v.String()
-			}
 			mux.Lock()
 			defer mux.Unlock()
-			clusters[contextName] = &ClientSet{
-				Name:       contextName,
-				Version:    version,
-				K8sClient:  k8sClient,
-				PromClient: promClient,
-			}
+			clusters[contextName] = cs
 		}(contextName)
 	}
 	wg.Wait()
+	return clusters
+}
+
+func createCmFromKubeconfig(kubeconfig string) (*ClusterManager, error) {
+	config, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	fingerprints := contextFingerprints(config)
+	clusters := buildContexts(config, slices.Collect(maps.Keys(fingerprints)))
 	if len(clusters) == 0 {
 		return nil, fmt.Errorf("no clusters found in kubeconfig: %s", kubeconfig)
 	}
+	for name := range fingerprints {
+		if _, ok := clusters[name]; !ok {
+			// Retried on the next kubeconfig change
+			delete(fingerprints, name)
+		}
+	}
 	klog.Infof("Loaded %d clusters from kubeconfig: %s, default cluster: %s", len(clusters), kubeconfig, config.CurrentContext)
-	return &ClusterManager{clusters: clusters, defaultContext: config.CurrentContext}, nil
+	return &ClusterManager{
+		clusters:       clusters,
+		defaultContext: config.CurrentContext,
+		kubeconfig:     kubeconfig,
+		fingerprints:   fingerprints,
+	}, nil
 }
 
 func NewClusterManager() (*ClusterManager, error) {
@@ -125,16 +234,40 @@ func NewClusterManager() (*ClusterManager, error) {
 		kubeconfig = envKubeconfig
 	}
 
-	_, err := os.Stat(kubeconfig)
-	if err == nil {
-		return createCmFromKubeconfig(kubeconfig)
+	var (
+		cm  *ClusterManager
+		err error
+	)
+	if _, statErr := os.Stat(kubeconfig); statErr == nil {
+		cm, err = createCmFromKubeconfig(kubeconfig)
+	} else {
+		cm, err = createCmInCluster()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cm.registered = make(map[string]bool)
+	if cm.store, err = newCredentialStore(); err != nil {
+		return nil, fmt.Errorf("failed to create cluster credential store: %w", err)
+	}
+	cm.loadRegisteredClusters()
+	go cm.runHealthChecks(context.Background())
+	if cm.kubeconfig != "" {
+		go func() {
+			if err := cm.watchKubeconfig(context.Background()); err != nil {
+				klog.Warningf("Failed to watch kubeconfig %s, changes need a restart: %v", cm.kubeconfig, err)
+			}
+		}()
 	}
-	return createCmInCluster()
+	return cm, nil
 }
 
 func (cm *ClusterManager) GetClientSet(clusterName string) (*ClientSet, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
 	if clusterName == "" {
-		return cm.GetClientSet(cm.defaultContext)
+		clusterName = cm.defaultContext
 	}
 	if cluster, ok := cm.clusters[clusterName]; ok {
 		return cluster, nil
@@ -142,21 +275,48 @@ func (cm *ClusterManager) GetClientSet(clusterName string) (*ClientSet, error) {
 	return nil, fmt.Errorf("cluster not found: %s", clusterName)
 }
 
+// ClientSets returns a snapshot of all clusters by name
+func (cm *ClusterManager) ClientSets() map[string]*ClientSet {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	clusters := make(map[string]*ClientSet, len(cm.clusters))
+	for name, cs := range cm.clusters {
+		clusters[name] = cs
+	}
+	return clusters
+}
+
 func (cm *ClusterManager) GetClusters(c *gin.Context) {
+	cm.mu.RLock()
 	result := make([]common.ClusterInfo, 0, len(cm.clusters))
 	for name, cluster := range cm.clusters {
-		result = append(result, common.ClusterInfo{
-			Name:      name,
-			Version:   cluster.Version,
-			IsDefault: name == cm.defaultContext,
-		})
+		result = append(result, cm.clusterInfoLocked(name, cluster))
 	}
+	cm.mu.RUnlock()
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Name < result[j].Name
 	})
 	c.JSON(200, result)
 }
 
+// clusterInfoLocked describes a cluster, the caller must hold cm.mu
+func (cm *ClusterManager) clusterInfoLocked(name string, cs *ClientSet) common.ClusterInfo {
+	health := cs.Health()
+	return common.ClusterInfo{
+		Name:       name,
+		Version:    cs.Version,
+		IsDefault:  name == cm.defaultContext,
+		Registered: cm.registered[name],
+		Health:     &health,
+	}
+}
+
+func (cm *ClusterManager) clusterInfo(name string, cs *ClientSet) common.ClusterInfo {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.clusterInfoLocked(name, cs)
+}
+
 func getPrometheusURL(clusterName string, k8sClient *kube.K8sClient) string {
 	// Try environment variables first (backwards compatibility)
 	envKey := utils.ToEnvName(clusterName) + "_PROMETHEUS_URL"
@@ -0,0 +1,170 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+const (
+	healthCheckInterval = 30 * time.Second
+	healthCheckTimeout  = 10 * time.Second
+	// watchErrorWindow is how long a watch error marks the cache unhealthy,
+	// informers retry on their own and usually recover within it
+	watchErrorWindow = time.Minute
+)
+
+var errMetricsServerNotInstalled = errors.New("metrics-server is not installed")
+
+// Health returns the result of the latest health check
+func (cs *ClientSet) Health() common.ClusterHealth {
+	cs.healthMu.RLock()
+	defer cs.healthMu.RUnlock()
+	if cs.health.Status == "" {
+		return common.ClusterHealth{Status: common.ClusterStatusUnknown}
+	}
+	return cs.health
+}
+
+// CheckHealth checks the API server, the informer cache, Prometheus and
+// metrics-server, and stores the result
+func (cs *ClientSet) CheckHealth(ctx context.Context) common.ClusterHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	prev := cs.Health()
+	now := time.Now()
+
+	var (
+		wg                                    sync.WaitGroup
+		apiErr, cacheErr, promErr, metricsErr error
+		promEnabled, metricsExists            = cs.PromClient != nil, true
+	)
+	cacheStatus := cs.K8sClient.CacheStatus()
+	switch {
+	case cacheStatus.State == kube.CacheStateSyncing:
+		cacheErr = errors.New("informer cache is syncing")
+	case cacheStatus.LastError != "" && now.Sub(cacheStatus.LastErrorTime) < watchErrorWindow:
+		cacheErr = fmt.Errorf("failed at %s: %s", cacheStatus.LastErrorTime.Format(time.RFC3339), cacheStatus.LastError)
+	}
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		apiErr = cs.K8sClient.ClientSet.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+	}()
+	go func() {
+		defer wg.Done()
+		if promEnabled {
+			promErr = cs.PromClient.HealthCheck(ctx)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		metricsErr = cs.K8sClient.ClientSet.Discovery().RESTClient().Get().AbsPath("/apis/metrics.k8s.io/v1beta1").Do(ctx).Error()
+		if apierrors.IsNotFound(metricsErr) {
+			metricsExists, metricsErr = false, errMetricsServerNotInstalled
+		}
+	}()
+	wg.Wait()
+
+	health := common.ClusterHealth{
+		APIServer:  *componentHealth(&prev.APIServer, now, apiErr),
+		CacheState: string(cacheStatus.State),
+	}
+	// Lazy caches are idle until the cluster is first used
+	if cacheStatus.State != kube.CacheStateDisabled && (cacheStatus.State != kube.CacheStateIdle || cacheErr != nil) {
+		health.Cache = componentHealth(prev.Cache, now, cacheErr)
+	}
+	if promEnabled {
+		health.Prometheus = componentHealth(prev.Prometheus, now, promErr)
+	}
+	if metricsExists {
+		health.MetricsServer = componentHealth(prev.MetricsServer, now, metricsErr)
+	}
+	health.Status = clusterStatus(&health)
+
+	if health.Status != prev.Status && prev.Status != common.ClusterStatusUnknown {
+		klog.Infof("Cluster %s is now %s (was %s)", cs.Name, health.Status, prev.Status)
+	}
+	cs.healthMu.Lock()
+	cs.health = health
+	cs.healthMu.Unlock()
+	return health
+}
+
+func componentHealth(prev *common.ComponentHealth, now time.Time, err error) *common.ComponentHealth {
+	component := &common.ComponentHealth{
+		Healthy:     err == nil,
+		LastChecked: now,
+	}
+	if err != nil {
+		component.Error = err.Error()
+		if prev != nil {
+			component.LastSeen = prev.LastSeen
+		}
+	} else {
+		component.LastSeen = &now
+	}
+	return component
+}
+
+func clusterStatus(health *common.ClusterHealth) common.ClusterStatus {
+	if !health.APIServer.Healthy {
+		return common.ClusterStatusUnreachable
+	}
+	if health.CacheState == string(kube.CacheStateSyncing) {
+		return common.ClusterStatusSyncing
+	}
+	for _, component := range []*common.ComponentHealth{health.Cache, health.Prometheus, health.MetricsServer} {
+		if component != nil && !component.Healthy {
+			return common.ClusterStatusDegraded
+		}
+	}
+	return common.ClusterStatusHealthy
+}
+
+// runHealthChecks checks every cluster concurrently at a fixed interval until ctx is done
+func (cm *ClusterManager) runHealthChecks(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		wg := sync.WaitGroup{}
+		for _, cs := range cm.ClientSets() {
+			wg.Add(1)
+			go func(cs *ClientSet) {
+				defer wg.Done()
+				cs.CheckHealth(ctx)
+			}(cs)
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckClusterHealth handles POST /clusters/:name/health, it re-checks a
+// cluster right away instead of waiting for the next periodic check
+func (cm *ClusterManager) CheckClusterHealth(c *gin.Context) {
+	name := c.Param("name")
+	cs, err := cm.GetClientSet(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	cs.CheckHealth(c.Request.Context())
+	c.JSON(http.StatusOK, cm.clusterInfo(name, cs))
+}
@@ -0,0 +1,33 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zxh326/kite/pkg/common"
+)
+
+func TestComponentHealthKeepsLastSeen(t *testing.T) {
+	first := time.Now()
+	healthy := componentHealth(nil, first, nil)
+	assert.True(t, healthy.Healthy)
+	assert.Equal(t, first, *healthy.LastSeen)
+
+	failed := componentHealth(healthy, first.Add(time.Minute), errors.New("connection refused"))
+	assert.False(t, failed.Healthy)
+	assert.Equal(t, "connection refused", failed.Error)
+	assert.Equal(t, first, *failed.LastSeen)
+}
+
+func TestClusterStatus(t *testing.T) {
+	ok := common.ComponentHealth{Healthy: true}
+	down := common.ComponentHealth{Error: "down"}
+
+	assert.Equal(t, common.ClusterStatusHealthy, clusterStatus(&common.ClusterHealth{APIServer: ok}))
+	assert.Equal(t, common.ClusterStatusUnreachable, clusterStatus(&common.ClusterHealth{APIServer: down, Cache: &ok}))
+	assert.Equal(t, common.ClusterStatusDegraded, clusterStatus(&common.ClusterHealth{APIServer: ok, Prometheus: &down}))
+	assert.Equal(t, common.ClusterStatusSyncing, clusterStatus(&common.ClusterHealth{APIServer: ok, CacheState: "syncing", Cache: &down}))
+}
@@ -0,0 +1,385 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+)
+
+// testTimeout bounds how long a connectivity test waits for the API server
+const testTimeout = 10 * time.Second
+
+// clusterNameRegexp keeps names usable as HTTP header values and Secret keys
+var clusterNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]{0,61}[a-zA-Z0-9])?$`)
+
+var (
+	ErrClusterExists        = errors.New("cluster already exists")
+	ErrClusterNotFound      = errors.New("cluster not found")
+	ErrClusterNotRegistered = errors.New("cluster is loaded from kubeconfig and cannot be changed at runtime")
+)
+
+// ClusterConfig holds the credentials of a cluster registered at runtime,
+// either a kubeconfig or a server URL with a bearer token
+type ClusterConfig struct {
+	Name string `json:"name"`
+
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// Context selects a kubeconfig context, defaults to the current context
+	Context string `json:"context,omitempty"`
+
+	Server string `json:"server,omitempty"`
+	Token  string `json:"token,omitempty"`
+	// CAData is the PEM encoded CA bundle, base64 encoded PEM is accepted too
+	CAData                string `json:"caData,omitempty"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify,omitempty"`
+
+	// PrometheusURL overrides Prometheus auto-discovery
+	PrometheusURL string `json:"prometheusURL,omitempty"`
+	// CacheKinds restricts the kinds kept in the informer cache
+	CacheKinds []string `json:"cacheKinds,omitempty"`
+}
+
+// Validate checks the config has a valid name and exactly one kind of credentials
+func (cfg *ClusterConfig) Validate() error {
+	if !clusterNameRegexp.MatchString(cfg.Name) {
+		return fmt.Errorf("invalid cluster name %q, use up to 63 letters, digits, '.', '_' or '-'", cfg.Name)
+	}
+	switch {
+	case cfg.Kubeconfig != "" && cfg.Server != "":
+		return errors.New("kubeconfig and server are mutually exclusive")
+	case cfg.Kubeconfig == "" && cfg.Server == "":
+		return errors.New("either kubeconfig or server is required")
+	case cfg.Server != "" && cfg.Token == "":
+		return errors.New("token is required when server is set")
+	}
+	return nil
+}
+
+// RESTConfig builds the client configuration from the credentials
+func (cfg *ClusterConfig) RESTConfig() (*rest.Config, error) {
+	if cfg.Kubeconfig != "" {
+		config, err := clientcmd.Load([]byte(cfg.Kubeconfig))
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+		}
+		if err := checkInlineCredentials(config); err != nil {
+			return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+		}
+		return clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{
+			CurrentContext: cfg.Context,
+		}).ClientConfig()
+	}
+
+	restConfig := &rest.Config{
+		Host:        cfg.Server,
+		BearerToken: cfg.Token,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: cfg.InsecureSkipTLSVerify,
+		},
+	}
+	if ca := strings.TrimSpace(cfg.CAData); ca != "" {
+		if !strings.HasPrefix(ca, "-----BEGIN") {
+			decoded, err := base64.StdEncoding.DecodeString(ca)
+			if err != nil {
+				return nil, fmt.Errorf("caData is neither PEM nor base64 encoded PEM: %w", err)
+			}
+			ca = string(decoded)
+		}
+		restConfig.CAData = []byte(ca)
+	}
+	return restConfig, nil
+}
+
+// checkInlineCredentials rejects kubeconfigs that make Kite run commands or
+// read files on its host, only inline certificate data and tokens are allowed
+func checkInlineCredentials(config *clientcmdapi.Config) error {
+	for name, authInfo := range config.AuthInfos {
+		switch {
+		case authInfo.Exec != nil:
+			return fmt.Errorf("user %q: exec credential plugins are not allowed", name)
+		case authInfo.AuthProvider != nil:
+			return fmt.Errorf("user %q: auth-provider plugins are not allowed", name)
+		case authInfo.ClientCertificate != "":
+			return fmt.Errorf("user %q: client-certificate files are not allowed, use client-certificate-data", name)
+		case authInfo.ClientKey != "":
+			return fmt.Errorf("user %q: client-key files are not allowed, use client-key-data", name)
+		case authInfo.TokenFile != "":
+			return fmt.Errorf("user %q: tokenFile is not allowed, use token", name)
+		}
+	}
+	for name, cluster := range config.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return fmt.Errorf("cluster %q: certificate-authority files are not allowed, use certificate-authority-data", name)
+		}
+	}
+	return nil
+}
+
+// TestCluster checks the API server is reachable with the given credentials
+// and returns its version
+func TestCluster(cfg *ClusterConfig) (string, error) {
+	restConfig, err := cfg.RESTConfig()
+	if err != nil {
+		return "", err
+	}
+	version, err := serverVersion(restConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach the API server: %w", err)
+	}
+	return version, nil
+}
+
+// buildRegisteredCluster creates the clients of a registered cluster
+func buildRegisteredCluster(cfg *ClusterConfig) (*ClientSet, error) {
+	restConfig, err := cfg.RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newClientSet(cfg.Name, restConfig, cfg.PrometheusURL, cfg.CacheKinds)
+}
+
+// testAndBuildCluster tests the credentials first so clusters registered
+// through the API are rejected right away when they are unreachable
+func testAndBuildCluster(cfg *ClusterConfig) (*ClientSet, error) {
+	if _, err := TestCluster(cfg); err != nil {
+		return nil, err
+	}
+	return buildRegisteredCluster(cfg)
+}
+
+// AddCluster registers a new cluster and persists its credentials
+func (cm *ClusterManager) AddCluster(ctx context.Context, cfg ClusterConfig) (*ClientSet, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cm.registerMu.Lock()
+	defer cm.registerMu.Unlock()
+	if _, err := cm.GetClientSet(cfg.Name); err == nil {
+		return nil, ErrClusterExists
+	}
+
+	cs, err := testAndBuildCluster(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := cm.saveCredentials(ctx, cfg); err != nil {
+		cs.K8sClient.Stop()
+		return nil, err
+	}
+
+	cm.mu.Lock()
+	cm.clusters[cfg.Name] = cs
+	cm.registered[cfg.Name] = true
+	cm.mu.Unlock()
+	cs.CheckHealth(ctx)
+	klog.Infof("Registered cluster %s", cfg.Name)
+	return cs, nil
+}
+
+// UpdateCluster replaces the credentials of a registered cluster, requests in
+// flight keep using the previous clients until they finish
+func (cm *ClusterManager) UpdateCluster(ctx context.Context, cfg ClusterConfig) (*ClientSet, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cm.registerMu.Lock()
+	defer cm.registerMu.Unlock()
+	if err := cm.checkRegistered(cfg.Name); err != nil {
+		return nil, err
+	}
+
+	cs, err := testAndBuildCluster(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := cm.saveCredentials(ctx, cfg); err != nil {
+		cs.K8sClient.Stop()
+		return nil, err
+	}
+
+	cm.mu.Lock()
+	old := cm.clusters[cfg.Name]
+	cm.clusters[cfg.Name] = cs
+	cm.mu.Unlock()
+	old.K8sClient.Stop()
+	cs.CheckHealth(ctx)
+	klog.Infof("Updated cluster %s", cfg.Name)
+	return cs, nil
+}
+
+// RemoveCluster unregisters a cluster and stops its informers
+func (cm *ClusterManager) RemoveCluster(ctx context.Context, name string) error {
+	cm.registerMu.Lock()
+	defer cm.registerMu.Unlock()
+	if err := cm.checkRegistered(name); err != nil {
+		return err
+	}
+	if cm.store != nil {
+		if err := cm.store.Delete(ctx, name); err != nil {
+			return fmt.Errorf("failed to delete cluster credentials: %w", err)
+		}
+	}
+
+	cm.mu.Lock()
+	cs := cm.clusters[name]
+	delete(cm.clusters, name)
+	delete(cm.registered, name)
+	cm.mu.Unlock()
+	cs.K8sClient.Stop()
+	klog.Infof("Removed cluster %s", name)
+	return nil
+}
+
+func (cm *ClusterManager) checkRegistered(name string) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if _, ok := cm.clusters[name]; !ok {
+		return ErrClusterNotFound
+	}
+	if !cm.registered[name] {
+		return ErrClusterNotRegistered
+	}
+	return nil
+}
+
+func (cm *ClusterManager) saveCredentials(ctx context.Context, cfg ClusterConfig) error {
+	if cm.store == nil {
+		klog.Warningf("CLUSTER_STORE is not set, cluster %s will be lost on restart", cfg.Name)
+		return nil
+	}
+	if err := cm.store.Save(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to save cluster credentials: %w", err)
+	}
+	return nil
+}
+
+// loadRegisteredClusters builds the clients of persisted clusters concurrently,
+// unreachable clusters are registered anyway and reported by health checks
+func (cm *ClusterManager) loadRegisteredClusters() {
+	if cm.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	configs, err := cm.store.List(ctx)
+	if err != nil {
+		klog.Warningf("Failed to load registered clusters: %v", err)
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, cfg := range configs {
+		if _, err := cm.GetClientSet(cfg.Name); err == nil {
+			klog.Warningf("Registered cluster %s conflicts with a kubeconfig context, skipping", cfg.Name)
+			continue
+		}
+		wg.Add(1)
+		go func(cfg ClusterConfig) {
+			defer wg.Done()
+			cs, err := buildRegisteredCluster(&cfg)
+			if err != nil {
+				klog.Warningf("Failed to load registered cluster %s: %v", cfg.Name, err)
+				return
+			}
+			cm.mu.Lock()
+			defer cm.mu.Unlock()
+			cm.clusters[cfg.Name] = cs
+			cm.registered[cfg.Name] = true
+			klog.Infof("Loaded registered cluster: %s", cfg.Name)
+		}(cfg)
+	}
+	wg.Wait()
+}
+
+func clusterErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrClusterExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrClusterNotRegistered):
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+// CreateCluster handles POST /clusters
+func (cm *ClusterManager) CreateCluster(c *gin.Context) {
+	var cfg ClusterConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cs, err := cm.AddCluster(c.Request.Context(), cfg)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, cm.clusterInfo(cs.Name, cs))
+}
+
+// UpdateClusterCredentials handles PUT /clusters/:name
+func (cm *ClusterManager) UpdateClusterCredentials(c *gin.Context) {
+	var cfg ClusterConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cfg.Name = c.Param("name")
+	cs, err := cm.UpdateCluster(c.Request.Context(), cfg)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, cm.clusterInfo(cs.Name, cs))
+}
+
+// DeleteCluster handles DELETE /clusters/:name
+func (cm *ClusterManager) DeleteCluster(c *gin.Context) {
+	name := c.Param("name")
+	if err := cm.RemoveCluster(c.Request.Context(), name); err != nil {
+		status := clusterErrorStatus(err)
+		if status == http.StatusBadRequest {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Cluster %s removed", name)})
+}
+
+// TestClusterConnection handles POST /clusters/test, it checks credentials
+// without registering the cluster
+func (cm *ClusterManager) TestClusterConnection(c *gin.Context) {
+	var cfg ClusterConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if cfg.Name == "" {
+		// The name is irrelevant for a connectivity test
+		cfg.Name = "test"
+	}
+	if err := cfg.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	version, err := TestCluster(&cfg)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": version})
+}
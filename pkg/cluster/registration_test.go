@@ -0,0 +1,58 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const registrationKubeconfig = `apiVersion: v1
+kind: Config
+current-context: prod
+contexts:
+- name: prod
+  context: {cluster: prod, user: admin}
+clusters:
+- name: prod
+  cluster:
+    server: https://prod:6443
+%s
+users:
+- name: admin
+  user:
+%s
+`
+
+func TestRESTConfigRejectsHostCredentials(t *testing.T) {
+	kubeconfig := func(cluster, user string) string {
+		return fmt.Sprintf(registrationKubeconfig, cluster, user)
+	}
+
+	restConfig, err := (&ClusterConfig{Name: "prod", Kubeconfig: kubeconfig(
+		"    certificate-authority-data: Y2E=",
+		"    token: secret",
+	)}).RESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", restConfig.BearerToken)
+
+	tests := map[string]string{
+		"exec": kubeconfig("", `    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: sh
+      args: ["-c", "id"]`),
+		"auth-provider":         kubeconfig("", "    auth-provider: {name: oidc, config: {idp-issuer-url: https://issuer}}"),
+		"client-certificate":    kubeconfig("", "    client-certificate: /etc/kubernetes/pki/admin.crt\n    client-key-data: a2V5"),
+		"client-key":            kubeconfig("", "    client-certificate-data: Y2VydA==\n    client-key: /etc/kubernetes/pki/admin.key"),
+		"tokenFile":             kubeconfig("", "    tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token"),
+		"certificate-authority": kubeconfig("    certificate-authority: /etc/kubernetes/pki/ca.crt", "    token: secret"),
+	}
+	for name, kubeconfig := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := (&ClusterConfig{Name: "prod", Kubeconfig: kubeconfig}).RESTConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), name)
+		})
+	}
+}
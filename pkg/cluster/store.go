@@ -0,0 +1,275 @@
+package cluster
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/zxh326/kite/pkg/common"
+)
+
+const (
+	// clusterStoreSecretName holds one key per registered cluster
+	clusterStoreSecretName = "kite-clusters"
+
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// CredentialStore persists the credentials of clusters registered at runtime
+type CredentialStore interface {
+	List(ctx context.Context) ([]ClusterConfig, error)
+	Save(ctx context.Context, config ClusterConfig) error
+	Delete(ctx context.Context, name string) error
+}
+
+// newCredentialStore creates the store selected by CLUSTER_STORE, nil keeps
+// registered clusters in memory only
+func newCredentialStore() (CredentialStore, error) {
+	switch common.ClusterStore {
+	case "":
+		return nil, nil
+	case "secret":
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("secret store requires running in a cluster: %w", err)
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		namespace := common.ClusterStoreNamespace
+		if namespace == "" {
+			namespace = "kube-system"
+			if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+				namespace = strings.TrimSpace(string(data))
+			}
+		}
+		return NewSecretStore(clientset, namespace), nil
+	case "file":
+		if common.ClusterStoreKey == "" {
+			return nil, errors.New("CLUSTER_STORE_KEY is required for the file store")
+		}
+		return NewFileStore(common.ClusterStorePath, common.ClusterStoreKey), nil
+	default:
+		return nil, fmt.Errorf("unknown cluster store: %s", common.ClusterStore)
+	}
+}
+
+// SecretStore keeps cluster credentials in a Kubernetes Secret
+type SecretStore struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+func NewSecretStore(client kubernetes.Interface, namespace string) *SecretStore {
+	return &SecretStore{client: client, namespace: namespace}
+}
+
+func (s *SecretStore) List(ctx context.Context) ([]ClusterConfig, error) {
+	secret, err := s.client.CoreV1().Secrets(s.namespace).Get(ctx, clusterStoreSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]ClusterConfig, 0, len(secret.Data))
+	for name, data := range secret.Data {
+		var config ClusterConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid credentials for cluster %s: %w", name, err)
+		}
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Name < configs[j].Name
+	})
+	return configs, nil
+}
+
+func (s *SecretStore) Save(ctx context.Context, config ClusterConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, func(secret *corev1.Secret) {
+		secret.Data[config.Name] = data
+	})
+}
+
+func (s *SecretStore) Delete(ctx context.Context, name string) error {
+	return s.update(ctx, func(secret *corev1.Secret) {
+		delete(secret.Data, name)
+	})
+}
+
+func (s *SecretStore) update(ctx context.Context, mutate func(secret *corev1.Secret)) error {
+	secrets := s.client.CoreV1().Secrets(s.namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(ctx, clusterStoreSecretName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      clusterStoreSecretName,
+					Namespace: s.namespace,
+					Labels:    map[string]string{"app.kubernetes.io/managed-by": "kite"},
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{},
+			}
+			mutate(secret)
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Lost the race to create it, retry as an update
+				return apierrors.NewConflict(corev1.Resource("secrets"), clusterStoreSecretName, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		mutate(secret)
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// FileStore keeps cluster credentials in a local file encrypted with AES-GCM
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+	key  [32]byte
+}
+
+// NewFileStore derives the AES-256 key from the given secret
+func NewFileStore(path, secret string) *FileStore {
+	return &FileStore{path: path, key: sha256.Sum256([]byte(secret))}
+}
+
+func (s *FileStore) List(ctx context.Context) ([]ClusterConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	configs, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ClusterConfig, 0, len(configs))
+	for _, config := range configs {
+		result = append(result, config)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (s *FileStore) Save(ctx context.Context, config ClusterConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	configs, err := s.read()
+	if err != nil {
+		return err
+	}
+	configs[config.Name] = config
+	return s.write(configs)
+}
+
+func (s *FileStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	configs, err := s.read()
+	if err != nil {
+		return err
+	}
+	delete(configs, name)
+	return s.write(configs)
+}
+
+func (s *FileStore) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *FileStore) read() (map[string]ClusterConfig, error) {
+	configs := make(map[string]ClusterConfig)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return configs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("cluster store file is corrupted")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt cluster store file, check CLUSTER_STORE_KEY")
+	}
+	if err := json.Unmarshal(plaintext, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// write replaces the file atomically so a crash never leaves it half written
+func (s *FileStore) write(configs map[string]ClusterConfig) error {
+	plaintext, err := json.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	gcm, err := s.gcm()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := gcm.Seal(nonce, nonce, plaintext, nil)
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".kite-clusters-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "clusters.enc")
+	store := NewFileStore(path, "secret")
+
+	configs, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+
+	require.NoError(t, store.Save(ctx, ClusterConfig{Name: "prod", Server: "https://prod:6443", Token: "token"}))
+	require.NoError(t, store.Save(ctx, ClusterConfig{Name: "dev", Server: "https://dev:6443", Token: "token"}))
+	require.NoError(t, store.Delete(ctx, "dev"))
+
+	configs, err = store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "https://prod:6443", configs[0].Server)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "prod:6443")
+
+	_, err = NewFileStore(path, "wrong").List(ctx)
+	assert.Error(t, err)
+}
+
+func TestClusterConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ClusterConfig
+		wantErr bool
+	}{
+		{"token", ClusterConfig{Name: "prod", Server: "https://prod:6443", Token: "token"}, false},
+		{"kubeconfig", ClusterConfig{Name: "prod", Kubeconfig: "apiVersion: v1"}, false},
+		{"invalid name", ClusterConfig{Name: "prod/eu", Server: "https://prod:6443", Token: "token"}, true},
+		{"no credentials", ClusterConfig{Name: "prod"}, true},
+		{"both credentials", ClusterConfig{Name: "prod", Kubeconfig: "apiVersion: v1", Server: "https://prod:6443"}, true},
+		{"missing token", ClusterConfig{Name: "prod", Server: "https://prod:6443"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
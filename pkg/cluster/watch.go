@@ -0,0 +1,168 @@
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+)
+
+// reloadDebounce coalesces the burst of events a single kubeconfig write produces
+const reloadDebounce = 500 * time.Millisecond
+
+// contextFingerprints hashes the effective configuration of every context,
+// including the referenced cluster, user and certificate files, so only the
+// contexts that really changed are rebuilt
+func contextFingerprints(config *clientcmdapi.Config) map[string]string {
+	fingerprints := make(map[string]string, len(config.Contexts))
+	for name := range config.Contexts {
+		contextConfig := config.DeepCopy()
+		contextConfig.CurrentContext = name
+		if err := clientcmdapi.MinifyConfig(contextConfig); err != nil {
+			klog.Warningf("Invalid kubeconfig context %s: %v", name, err)
+			continue
+		}
+		if err := clientcmdapi.FlattenConfig(contextConfig); err != nil {
+			klog.V(2).Infof("Failed to inline files of kubeconfig context %s: %v", name, err)
+		}
+		data, err := clientcmd.Write(*contextConfig)
+		if err != nil {
+			klog.Warningf("Failed to serialize kubeconfig context %s: %v", name, err)
+			continue
+		}
+		sum := sha256.Sum256(data)
+		fingerprints[name] = hex.EncodeToString(sum[:])
+	}
+	return fingerprints
+}
+
+// watchKubeconfig reloads the kubeconfig whenever it changes on disk until ctx is done
+func (cm *ClusterManager) watchKubeconfig(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = watcher.Close()
+	}()
+	// Watch the directory, editors and ConfigMap mounts replace the file
+	// instead of writing to it, which drops watches on the file itself
+	if err := watcher.Add(filepath.Dir(cm.kubeconfig)); err != nil {
+		return err
+	}
+	klog.Infof("Watching kubeconfig %s for changes", cm.kubeconfig)
+
+	var debounce *time.Timer
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !cm.isKubeconfigEvent(event) {
+				continue
+			}
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.AfterFunc(reloadDebounce, cm.reloadKubeconfig)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			klog.Warningf("Kubeconfig watch error: %v", err)
+		}
+	}
+}
+
+func (cm *ClusterManager) isKubeconfigEvent(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	// ConfigMap and Secret volumes swap the ..data symlink on updates
+	return name == filepath.Base(cm.kubeconfig) || name == "..data"
+}
+
+// reloadKubeconfig rebuilds the clients of new and changed contexts and stops
+// the ones of removed contexts. Clients are swapped under the lock, requests in
+// flight keep using the previous clients until they finish.
+func (cm *ClusterManager) reloadKubeconfig() {
+	config, err := clientcmd.LoadFromFile(cm.kubeconfig)
+	if err != nil {
+		klog.Warningf("Failed to reload kubeconfig %s, keeping current clusters: %v", cm.kubeconfig, err)
+		return
+	}
+	fingerprints := contextFingerprints(config)
+
+	cm.registerMu.Lock()
+	defer cm.registerMu.Unlock()
+
+	var changed, removed []string
+	cm.mu.RLock()
+	for name, fingerprint := range fingerprints {
+		old, loaded := cm.fingerprints[name]
+		if old == fingerprint {
+			continue
+		}
+		if !loaded && cm.registered[name] {
+			klog.Warningf("Kubeconfig context %s conflicts with a registered cluster, skipping", name)
+			continue
+		}
+		changed = append(changed, name)
+	}
+	for name := range cm.fingerprints {
+		if _, ok := fingerprints[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	cm.mu.RUnlock()
+
+	// Contexts that fail to build keep their previous clients and are retried
+	// on the next change
+	built := buildContexts(config, changed)
+
+	var stale []*ClientSet
+	cm.mu.Lock()
+	for name, cs := range built {
+		if old, ok := cm.clusters[name]; ok {
+			stale = append(stale, old)
+		}
+		cm.clusters[name] = cs
+		cm.fingerprints[name] = fingerprints[name]
+	}
+	for _, name := range removed {
+		if old, ok := cm.clusters[name]; ok {
+			stale = append(stale, old)
+			delete(cm.clusters, name)
+		}
+		delete(cm.fingerprints, name)
+	}
+	if _, ok := cm.clusters[config.CurrentContext]; ok {
+		cm.defaultContext = config.CurrentContext
+	}
+	cm.mu.Unlock()
+
+	for _, cs := range stale {
+		cs.K8sClient.Stop()
+	}
+	for _, cs := range built {
+		go cs.CheckHealth(context.Background())
+	}
+	if len(built) > 0 || len(removed) > 0 {
+		klog.Infof("Reloaded kubeconfig %s: %d contexts rebuilt, %d removed", cm.kubeconfig, len(built), len(removed))
+	}
+}
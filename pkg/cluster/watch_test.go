@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+func testKubeconfig(tokens map[string]string, current string) *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	for name, token := range tokens {
+		config.Clusters[name] = &clientcmdapi.Cluster{Server: "https://" + name + ":6443"}
+		config.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: token}
+		config.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	}
+	config.CurrentContext = current
+	return config
+}
+
+func TestContextFingerprints(t *testing.T) {
+	before := contextFingerprints(testKubeconfig(map[string]string{"prod": "a", "dev": "b"}, "prod"))
+	after := contextFingerprints(testKubeconfig(map[string]string{"prod": "a", "dev": "rotated"}, "dev"))
+
+	require.Len(t, before, 2)
+	assert.Equal(t, before["prod"], after["prod"], "switching the current context is not a change")
+	assert.NotEqual(t, before["dev"], after["dev"])
+}
+
+func TestReloadKubeconfigRemovesContexts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, clientcmd.WriteToFile(*testKubeconfig(map[string]string{"prod": "a", "dev": "b"}, "prod"), path))
+	config, err := clientcmd.LoadFromFile(path)
+	require.NoError(t, err)
+
+	prod := &ClientSet{Name: "prod", K8sClient: &kube.K8sClient{}}
+	cm := &ClusterManager{
+		clusters: map[string]*ClientSet{
+			"prod": prod,
+			"dev":  {Name: "dev", K8sClient: &kube.K8sClient{}},
+		},
+		defaultContext: "dev",
+		registered:     map[string]bool{},
+		kubeconfig:     path,
+		fingerprints:   contextFingerprints(config),
+	}
+
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, clientcmd.WriteToFile(*testKubeconfig(map[string]string{"prod": "a"}, "prod"), path))
+	cm.reloadKubeconfig()
+
+	cs, err := cm.GetClientSet("")
+	require.NoError(t, err)
+	assert.Same(t, prod, cs, "unchanged contexts keep their clients")
+	_, err = cm.GetClientSet("dev")
+	assert.Error(t, err)
+	assert.NotContains(t, cm.fingerprints, "dev")
+}
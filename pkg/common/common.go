@@ -2,6 +2,8 @@ package common
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"k8s.io/klog/v2"
 
@@ -13,7 +15,14 @@ const (
 
 	NodeTerminalPodName = "kite-node-terminal-agent"
 
+	// DebugContainerPrefix names ephemeral debug containers and debug pod copies
+	DebugContainerPrefix = "kite-debug"
+
 	KubectlAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+	// RegistryUpdateAnnotation selects how a workload follows registry
+	// pushes of its images: restart, digest or disabled
+	RegistryUpdateAnnotation = "kite.io/registry-update"
 )
 
 var (
@@ -26,14 +35,56 @@ var (
 
 	NodeTerminalImage = "busybox:latest"
 
-	WebhookUsername = "kite-webhook"
-	WebhookPassword = "kite-webhook-password"
+	// DebugImage is the default image of debug containers, started in the
+	// terminal for images without a shell
+	DebugImage = "busybox:latest"
+
+	// WebhookUsername and WebhookPassword are the global webhook credentials,
+	// the default password is only accepted while no webhook token exists
+	// in a persistent token store
+	WebhookUsername        = "kite-webhook"
+	WebhookPassword        = "kite-webhook-password"
+	WebhookDefaultPassword = true
+
+	// WebhookTokenStore keeps the named webhook tokens: file or memory, by
+	// default file when WebhookTokenPath is set and memory otherwise
+	WebhookTokenStore = ""
+	WebhookTokenPath  = ""
+
+	// RegistryWebhookRequireAnnotation limits registry webhooks to workloads
+	// with the RegistryUpdateAnnotation
+	RegistryWebhookRequireAnnotation = true
 
 	KiteUsername         = os.Getenv("KITE_USERNAME")
 	KitePassword         = os.Getenv("KITE_PASSWORD")
 	PasswordLoginEnabled = KiteUsername != "" && KitePassword != ""
 
 	Readonly = false
+
+	// EnableImpersonation makes Kite impersonate the logged-in user on the Kubernetes API
+	EnableImpersonation = false
+
+	// RBACConfigPath is the role bindings file, empty disables role checks
+	RBACConfigPath = ""
+
+	// AuditSink selects where audit entries go: memory, stdout, file or sqlite
+	AuditSink = "memory"
+	AuditPath = ""
+
+	// RecordingStore keeps terminal session recordings: file, memory or none,
+	// by default file when RecordingPath is set and memory otherwise
+	RecordingStore = ""
+	RecordingPath  = ""
+	// RecordingRetention and RecordingMaxCount bound the recordings kept
+	RecordingRetention = 30 * 24 * time.Hour
+	RecordingMaxCount  = 1000
+
+	// ClusterStore persists clusters registered at runtime: secret, file or
+	// empty to keep them in memory only
+	ClusterStore          = ""
+	ClusterStoreNamespace = ""
+	ClusterStorePath      = "kite-clusters.enc"
+	ClusterStoreKey       = ""
 )
 
 func LoadEnvs() {
@@ -53,7 +104,7 @@ func LoadEnvs() {
 		}
 		if allowUsers := os.Getenv("OAUTH_ALLOW_USERS"); allowUsers != "" {
 			OAuthAllowUsers = allowUsers
-		} else {
+		} else if os.Getenv("RBAC_CONFIG") == "" {
 			klog.Warning("OAUTH_ALLOW_USERS is not set, OAuth will not work as expected")
 		}
 	} else {
@@ -72,15 +123,72 @@ func LoadEnvs() {
 		NodeTerminalImage = nodeTerminalImage
 	}
 
+	if debugImage := os.Getenv("DEBUG_IMAGE"); debugImage != "" {
+		DebugImage = debugImage
+	}
+
 	if webhookUsername := os.Getenv("WEBHOOK_USERNAME"); webhookUsername != "" {
 		WebhookUsername = webhookUsername
 	}
 	if webhookPassword := os.Getenv("WEBHOOK_PASSWORD"); webhookPassword != "" {
 		WebhookPassword = webhookPassword
-	} else {
-		klog.Warning("WEBHOOK_PASSWORD is not set, using default password")
+		WebhookDefaultPassword = false
+	}
+	if tokenStore := os.Getenv("WEBHOOK_TOKEN_STORE"); tokenStore != "" {
+		WebhookTokenStore = tokenStore
+	}
+	if tokenPath := os.Getenv("WEBHOOK_TOKEN_PATH"); tokenPath != "" {
+		WebhookTokenPath = tokenPath
+	}
+	if requireAnnotation := os.Getenv("REGISTRY_WEBHOOK_REQUIRE_ANNOTATION"); requireAnnotation == "false" {
+		RegistryWebhookRequireAnnotation = false
 	}
 	if readonly := os.Getenv("READONLY"); readonly == "true" {
 		Readonly = true
 	}
+	if rbacConfig := os.Getenv("RBAC_CONFIG"); rbacConfig != "" {
+		RBACConfigPath = rbacConfig
+	}
+	if auditSink := os.Getenv("AUDIT_SINK"); auditSink != "" {
+		AuditSink = auditSink
+	}
+	if auditPath := os.Getenv("AUDIT_PATH"); auditPath != "" {
+		AuditPath = auditPath
+	}
+	if recordingStore := os.Getenv("RECORDING_STORE"); recordingStore != "" {
+		RecordingStore = recordingStore
+	}
+	if recordingPath := os.Getenv("RECORDING_PATH"); recordingPath != "" {
+		RecordingPath = recordingPath
+	}
+	if v := os.Getenv("RECORDING_RETENTION"); v != "" {
+		if retention, err := time.ParseDuration(v); err == nil {
+			RecordingRetention = retention
+		} else {
+			klog.Warningf("Invalid RECORDING_RETENTION %q, using %s", v, RecordingRetention)
+		}
+	}
+	if v := os.Getenv("RECORDING_MAX_COUNT"); v != "" {
+		if maxCount, err := strconv.Atoi(v); err == nil {
+			RecordingMaxCount = maxCount
+		} else {
+			klog.Warningf("Invalid RECORDING_MAX_COUNT %q, using %d", v, RecordingMaxCount)
+		}
+	}
+	if clusterStore := os.Getenv("CLUSTER_STORE"); clusterStore != "" {
+		ClusterStore = clusterStore
+	}
+	if namespace := os.Getenv("CLUSTER_STORE_NAMESPACE"); namespace != "" {
+		ClusterStoreNamespace = namespace
+	}
+	if path := os.Getenv("CLUSTER_STORE_PATH"); path != "" {
+		ClusterStorePath = path
+	}
+	ClusterStoreKey = os.Getenv("CLUSTER_STORE_KEY")
+	if impersonation := os.Getenv("ENABLE_IMPERSONATION"); impersonation == "true" {
+		EnableImpersonation = true
+		if !OAuthEnabled && !PasswordLoginEnabled {
+			klog.Warning("ENABLE_IMPERSONATION is set but no login method is enabled, requests will not be impersonated")
+		}
+	}
 }
@@ -1,11 +1,25 @@
 package common
 
+import "time"
+
 type SearchResult struct {
 	ID           string `json:"id"`
 	Name         string `json:"name"`
 	Namespace    string `json:"namespace,omitempty"`
 	ResourceType string `json:"resourceType"`
 	CreatedAt    string `json:"createdAt"`
+	// Match describes the field that matched when it isn't the name, like "image nginx:1.27"
+	Match string `json:"match,omitempty"`
+	// Score ranks results, exact name matches score highest
+	Score int `json:"score,omitempty"`
+	// Cluster is set by searches across clusters
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// ClusterError reports a cluster that failed in a request across clusters
+type ClusterError struct {
+	Cluster string `json:"cluster"`
+	Error   string `json:"error"`
 }
 
 type RelatedResource struct {
@@ -31,6 +45,11 @@ type WebhookRequest struct {
 	// Optional data for the action
 	// ActionUpdateImage => containerName:ImageName
 	Data string `json:"data,omitempty" binding:"required_if=Action updateImage"` // Must be printable ASCII characters
+
+	// Wait makes updateImage respond once the rollout completed, or failed
+	// after TimeoutSeconds (default 300, at most 1800)
+	Wait           bool `json:"wait,omitempty"`
+	TimeoutSeconds int  `json:"timeoutSeconds,omitempty" binding:"omitempty,min=1,max=1800"`
 }
 
 type Resource struct {
@@ -53,4 +72,38 @@ type ClusterInfo struct {
 	Name      string `json:"name"`
 	Version   string `json:"version"`
 	IsDefault bool   `json:"isDefault"`
+	// Registered clusters were added at runtime and can be updated or removed
+	Registered bool           `json:"registered"`
+	Health     *ClusterHealth `json:"health,omitempty"`
+}
+
+type ClusterStatus string
+
+const (
+	ClusterStatusUnknown     ClusterStatus = "unknown"
+	ClusterStatusHealthy     ClusterStatus = "healthy"
+	ClusterStatusSyncing     ClusterStatus = "syncing"
+	ClusterStatusDegraded    ClusterStatus = "degraded"
+	ClusterStatusUnreachable ClusterStatus = "unreachable"
+)
+
+// ComponentHealth is the result of the latest check of a cluster component
+type ComponentHealth struct {
+	Healthy     bool      `json:"healthy"`
+	LastChecked time.Time `json:"lastChecked"`
+	// LastSeen is the last time the component was healthy
+	LastSeen *time.Time `json:"lastSeen,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// ClusterHealth is refreshed by periodic background checks. Optional
+// components are nil when they are disabled or not installed.
+type ClusterHealth struct {
+	Status    ClusterStatus   `json:"status"`
+	APIServer ComponentHealth `json:"apiServer"`
+	// CacheState is disabled, idle (lazy and not used yet), syncing or synced
+	CacheState    string           `json:"cacheState"`
+	Cache         *ComponentHealth `json:"cache,omitempty"`
+	Prometheus    *ComponentHealth `json:"prometheus,omitempty"`
+	MetricsServer *ComponentHealth `json:"metricsServer,omitempty"`
 }
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zxh326/kite/pkg/audit"
+	"github.com/zxh326/kite/pkg/cluster"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// GetAuditLogs queries the audit trail of the selected cluster, newest first
+func GetAuditLogs(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+
+	filter := audit.Filter{
+		Cluster:   cs.Name,
+		User:      c.Query("user"),
+		Namespace: c.Query("namespace"),
+		Resource:  c.Query("resource"),
+		Name:      c.Query("name"),
+		Verb:      c.Query("verb"),
+		Limit:     defaultAuditLimit,
+	}
+	if v := c.Query("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid success parameter"})
+			return
+		}
+		filter.Success = &success
+	}
+	for param, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + " parameter, expected RFC3339 time"})
+				return
+			}
+			*target = t
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+		filter.Limit = min(limit, maxAuditLimit)
+	}
+
+	entries, err := audit.Query(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query audit logs: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": entries,
+	})
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/kube"
+	"github.com/zxh326/kite/pkg/recording"
+	"github.com/zxh326/kite/pkg/utils"
+)
+
+const (
+	// debugModeEphemeral adds an ephemeral container to the running pod
+	debugModeEphemeral = "ephemeral"
+	// debugModeCopy starts a copy of the pod with the command of a container
+	// replaced, for containers that crash before a shell can be opened
+	debugModeCopy = "copy"
+
+	debugContainerTimeout = 60 * time.Second
+
+	// debugSourceAnnotation records the pod a debug copy was made from
+	debugSourceAnnotation = "kite.io/debug-source"
+)
+
+// debugOptions are the query parameters of a debug terminal
+type debugOptions struct {
+	mode string
+	// image of the ephemeral container, or the replacement image of the
+	// debugged container of a copy
+	image string
+	// target is the container whose process namespace an ephemeral container
+	// joins, or the container of a copy that gets the command
+	target  string
+	command []string
+}
+
+// ephemeralDebugContainer returns an interactive ephemeral container sharing
+// the process namespace of target
+func ephemeralDebugContainer(pod *corev1.Pod, opts debugOptions) (*corev1.EphemeralContainer, error) {
+	if findContainer(pod.Spec.Containers, opts.target) == nil {
+		return nil, fmt.Errorf("container %s not found in pod %s", opts.target, pod.Name)
+	}
+	image := opts.image
+	if image == "" {
+		image = common.DebugImage
+	}
+	return &corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     fmt.Sprintf("%s-%s", common.DebugContainerPrefix, utils.RandomString(5)),
+			Image:                    image,
+			Command:                  opts.command,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: opts.target,
+	}, nil
+}
+
+// debugPodCopy returns a copy of pod that runs command in the target
+// container. Labels and owners are dropped so the copy receives no traffic
+// and no controller adopts it, probes are dropped so it isn't restarted.
+func debugPodCopy(pod *corev1.Pod, opts debugOptions) (*corev1.Pod, error) {
+	spec := pod.Spec.DeepCopy()
+	container := findContainer(spec.Containers, opts.target)
+	if container == nil {
+		return nil, fmt.Errorf("container %s not found in pod %s", opts.target, pod.Name)
+	}
+	if opts.image != "" {
+		container.Image = opts.image
+	}
+	container.Command = opts.command
+	if len(container.Command) == 0 {
+		container.Command = []string{"sh"}
+	}
+	container.Args = nil
+	container.Stdin = true
+	container.TTY = true
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+
+	spec.EphemeralContainers = nil
+	spec.NodeName = ""
+	spec.RestartPolicy = corev1.RestartPolicyNever
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%s", pod.Name, common.DebugContainerPrefix, utils.RandomString(5)),
+			Namespace: pod.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "kite",
+			},
+			Annotations: map[string]string{
+				debugSourceAnnotation: pod.Name,
+			},
+		},
+		Spec: *spec,
+	}, nil
+}
+
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+// startEphemeralDebug adds the debug container to the pod and attaches the
+// terminal to it. Ephemeral containers can't be removed, it stays in the pod
+// spec after it exits.
+func (h *TerminalHandler) startEphemeralDebug(ctx context.Context, c *gin.Context, cs *cluster.ClientSet, conn *websocket.Conn, pod *corev1.Pod, opts debugOptions) error {
+	debugContainer, err := ephemeralDebugContainer(pod, opts)
+	if err != nil {
+		return err
+	}
+	pod = pod.DeepCopy()
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, *debugContainer)
+	if _, err := cs.K8sClient.ClientSet.CoreV1().Pods(pod.Namespace).UpdateEphemeralContainers(ctx, pod.Name, pod, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to add ephemeral container: %w", err)
+	}
+	klog.Infof("Added debug container %s to pod %s/%s", debugContainer.Name, pod.Namespace, pod.Name)
+
+	if err := waitForContainerRunning(ctx, cs, conn, pod.Namespace, pod.Name, debugContainer.Name); err != nil {
+		return err
+	}
+	session := kube.NewTerminalSession(cs.K8sClient, conn, pod.Namespace, pod.Name, debugContainer.Name)
+	defer session.Close()
+	defer recordSession(c, session, &recording.Recording{
+		Kind:      recording.KindDebug,
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Container: debugContainer.Name,
+	})()
+	if err := session.Start(ctx, "attach"); err != nil {
+		// Already reported on the terminal by the session
+		klog.Errorf("Terminal session error: %v", err)
+	}
+	return nil
+}
+
+// startCopyDebug creates the debug copy of the pod, attaches the terminal to
+// it and deletes it when the terminal is closed
+func (h *TerminalHandler) startCopyDebug(ctx context.Context, c *gin.Context, cs *cluster.ClientSet, conn *websocket.Conn, pod *corev1.Pod, opts debugOptions) error {
+	debugPod, err := debugPodCopy(pod, opts)
+	if err != nil {
+		return err
+	}
+	if err := cs.K8sClient.Create(ctx, debugPod); err != nil {
+		return fmt.Errorf("failed to create debug pod: %w", err)
+	}
+	defer func() {
+		klog.Infof("Cleaning up debug pod %s/%s", debugPod.Namespace, debugPod.Name)
+		if err := cs.K8sClient.ClientSet.CoreV1().Pods(debugPod.Namespace).Delete(context.Background(), debugPod.Name, metav1.DeleteOptions{}); err != nil {
+			klog.Errorf("Failed to cleanup debug pod %s: %v", debugPod.Name, err)
+		}
+	}()
+
+	if err := waitForContainerRunning(ctx, cs, conn, debugPod.Namespace, debugPod.Name, opts.target); err != nil {
+		return err
+	}
+	session := kube.NewTerminalSession(cs.K8sClient, conn, debugPod.Namespace, debugPod.Name, opts.target)
+	defer session.Close()
+	defer recordSession(c, session, &recording.Recording{
+		Kind:      recording.KindDebug,
+		Namespace: debugPod.Namespace,
+		Pod:       debugPod.Name,
+		Container: opts.target,
+	})()
+	if err := session.Start(ctx, "attach"); err != nil {
+		// Already reported on the terminal by the session
+		klog.Errorf("Terminal session error: %v", err)
+	}
+	return nil
+}
+
+// waitForContainerRunning polls until the container, regular or ephemeral,
+// runs, and fails early when its image can't be pulled or it exited
+func waitForContainerRunning(ctx context.Context, cs *cluster.ClientSet, conn *websocket.Conn, namespace, podName, container string) error {
+	timeout := time.After(debugContainerTimeout)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	sendTerminalMessage(conn, "info", fmt.Sprintf("waiting for container %s to start", container))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("timeout waiting for container %s to start", container)
+		case <-ticker.C:
+			pod, err := cs.K8sClient.ClientSet.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			statuses := append(append([]corev1.ContainerStatus{}, pod.Status.ContainerStatuses...), pod.Status.EphemeralContainerStatuses...)
+			for _, status := range statuses {
+				if status.Name != container {
+					continue
+				}
+				switch {
+				case status.State.Running != nil:
+					sendTerminalMessage(conn, "info", "ready!")
+					return nil
+				case status.State.Terminated != nil:
+					return fmt.Errorf("container %s exited: %s %s", container, status.State.Terminated.Reason, status.State.Terminated.Message)
+				case status.State.Waiting != nil && isImagePullFailure(status.State.Waiting.Reason):
+					return fmt.Errorf("container %s: %s %s", container, status.State.Waiting.Reason, status.State.Waiting.Message)
+				}
+			}
+			sendTerminalMessage(conn, "stdout", ".")
+		}
+	}
+}
+
+func isImagePullFailure(reason string) bool {
+	switch reason {
+	case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+		return true
+	}
+	return false
+}
+
+func sendTerminalMessage(conn *websocket.Conn, msgType, data string) {
+	if err := websocket.JSON.Send(conn, kube.TerminalMessage{Type: msgType, Data: data}); err != nil {
+		klog.Errorf("Send message error: %v", err)
+	}
+}
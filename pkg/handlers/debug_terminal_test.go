@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/zxh326/kite/pkg/common"
+)
+
+func newDebugTestPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api-7d9f",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "api"},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "api"},
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "worker-1",
+			Containers: []corev1.Container{{
+				Name:          "api",
+				Image:         "gcr.io/distroless/static",
+				Args:          []string{"--port=8080"},
+				LivenessProbe: &corev1.Probe{},
+			}},
+		},
+	}
+}
+
+func TestEphemeralDebugContainer(t *testing.T) {
+	pod := newDebugTestPod()
+	container, err := ephemeralDebugContainer(pod, debugOptions{target: "api"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(container.Name, common.DebugContainerPrefix+"-"))
+	assert.Equal(t, common.DebugImage, container.Image)
+	assert.Equal(t, "api", container.TargetContainerName)
+	assert.True(t, container.Stdin)
+	assert.True(t, container.TTY)
+
+	container, err = ephemeralDebugContainer(pod, debugOptions{target: "api", image: "nicolaka/netshoot"})
+	require.NoError(t, err)
+	assert.Equal(t, "nicolaka/netshoot", container.Image)
+
+	_, err = ephemeralDebugContainer(pod, debugOptions{target: "missing"})
+	assert.Error(t, err)
+}
+
+func TestDebugPodCopy(t *testing.T) {
+	pod := newDebugTestPod()
+	debugPod, err := debugPodCopy(pod, debugOptions{target: "api", image: "busybox", command: []string{"sleep", "3600"}})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(debugPod.Name, "api-7d9f-"+common.DebugContainerPrefix+"-"))
+	assert.Equal(t, "default", debugPod.Namespace)
+	assert.NotContains(t, debugPod.Labels, "app")
+	assert.Empty(t, debugPod.OwnerReferences)
+	assert.Equal(t, "api-7d9f", debugPod.Annotations[debugSourceAnnotation])
+	assert.Empty(t, debugPod.Spec.NodeName)
+
+	container := debugPod.Spec.Containers[0]
+	assert.Equal(t, "busybox", container.Image)
+	assert.Equal(t, []string{"sleep", "3600"}, container.Command)
+	assert.Nil(t, container.Args)
+	assert.Nil(t, container.LivenessProbe)
+	assert.True(t, container.TTY)
+
+	// The source pod is left untouched
+	assert.Equal(t, "gcr.io/distroless/static", pod.Spec.Containers[0].Image)
+	assert.NotNil(t, pod.Spec.Containers[0].LivenessProbe)
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Log levels, ordered by severity
+const (
+	logLevelUnknown = iota
+	logLevelTrace
+	logLevelDebug
+	logLevelInfo
+	logLevelWarn
+	logLevelError
+	logLevelFatal
+)
+
+var logLevelNames = []string{"", "trace", "debug", "info", "warn", "error", "fatal"}
+
+// logLevelAliases maps the spellings found in log lines and in the level
+// query parameter to a level
+var logLevelAliases = map[string]int{
+	"trace":    logLevelTrace,
+	"trc":      logLevelTrace,
+	"debug":    logLevelDebug,
+	"dbg":      logLevelDebug,
+	"info":     logLevelInfo,
+	"inf":      logLevelInfo,
+	"notice":   logLevelInfo,
+	"warn":     logLevelWarn,
+	"warning":  logLevelWarn,
+	"wrn":      logLevelWarn,
+	"error":    logLevelError,
+	"err":      logLevelError,
+	"fatal":    logLevelFatal,
+	"panic":    logLevelFatal,
+	"critical": logLevelFatal,
+	"crit":     logLevelFatal,
+}
+
+var (
+	// textLevelPattern finds level=warn, [ERROR], "INFO " and similar markers
+	textLevelPattern = regexp.MustCompile(`(?i)(?:^|[\s\[(|"'])(?:level=|lvl=|severity=)?"?(trace|trc|debug|dbg|info|inf|notice|warn|warning|wrn|error|err|fatal|panic|critical|crit)"?(?:$|[\s\]):|,"'])`)
+	// klogLevelPattern matches the klog/glog header, e.g. "E0612 10:00:00.000000"
+	klogLevelPattern = regexp.MustCompile(`^([IWEF])\d{4} \d{2}:\d{2}:\d{2}`)
+)
+
+// jsonLevelKeys are the fields checked, in order, for the level of a JSON line
+var jsonLevelKeys = []string{"level", "lvl", "severity", "log.level", "loglevel"}
+
+// jsonMessageKeys are the fields checked, in order, for the message of a JSON line
+var jsonMessageKeys = []string{"msg", "message", "log"}
+
+// logFilter holds the filtering options of a logs request:
+//
+//	include   only lines matching the regular expression
+//	exclude   drop lines matching the regular expression
+//	match     only lines containing the text, case-insensitive
+//	level     only lines at or above the level (trace, debug, info, warn, error, fatal)
+//	format    "json" to return parsed entries instead of raw lines
+type logFilter struct {
+	include    *regexp.Regexp
+	exclude    *regexp.Regexp
+	match      *regexp.Regexp
+	minLevel   int
+	structured bool
+
+	// lastLevel is the level of the previous line, lines without a level
+	// marker, like stack traces, belong to it
+	lastLevel int
+}
+
+// logEntry is a log line in the structured mode
+type logEntry struct {
+	Line       string         `json:"line"`
+	Timestamp  string         `json:"timestamp,omitempty"`
+	Level      string         `json:"level,omitempty"`
+	Message    string         `json:"message"`
+	Fields     map[string]any `json:"fields,omitempty"`
+	Highlights [][2]int       `json:"highlights,omitempty"`
+}
+
+func parseLogFilter(c *gin.Context) (*logFilter, error) {
+	f := &logFilter{
+		structured: c.Query("format") == "json",
+	}
+	var err error
+	if match := c.Query("match"); match != "" {
+		f.match = regexp.MustCompile("(?i)" + regexp.QuoteMeta(match))
+	}
+	if include := c.Query("include"); include != "" {
+		if f.include, err = regexp.Compile(include); err != nil {
+			return nil, fmt.Errorf("invalid include parameter: %v", err)
+		}
+	}
+	if exclude := c.Query("exclude"); exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("invalid exclude parameter: %v", err)
+		}
+	}
+	if level := c.Query("level"); level != "" {
+		var ok bool
+		if f.minLevel, ok = logLevelAliases[strings.ToLower(level)]; !ok {
+			return nil, fmt.Errorf("invalid level parameter: %s", level)
+		}
+	}
+	if format := c.Query("format"); format != "" && format != "json" && format != "text" {
+		return nil, fmt.Errorf("invalid format parameter: %s", format)
+	}
+	return f, nil
+}
+
+// active reports whether lines need to be inspected at all
+func (f *logFilter) active() bool {
+	return f.include != nil || f.exclude != nil || f.match != nil || f.minLevel != logLevelUnknown || f.structured
+}
+
+// apply inspects a line, which may start with a timestamp, and reports
+// whether it passes the filter. Lines must be passed in order since lines
+// without a level inherit the level of the line before.
+func (f *logFilter) apply(line string) (*logEntry, bool) {
+	entry := &logEntry{Line: line, Message: line}
+	if ts, rest, ok := strings.Cut(line, " "); ok {
+		if _, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			entry.Timestamp = ts
+			entry.Message = rest
+		}
+	}
+
+	level := logLevelUnknown
+	if strings.HasPrefix(entry.Message, "{") {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(entry.Message), &fields); err == nil {
+			level = jsonLevel(fields)
+			if f.structured {
+				entry.Fields = fields
+				for _, key := range jsonMessageKeys {
+					if msg, ok := fields[key].(string); ok {
+						entry.Message = msg
+						break
+					}
+				}
+			}
+		}
+	}
+	if level == logLevelUnknown {
+		level = textLevel(entry.Message)
+	}
+	if level == logLevelUnknown {
+		level = f.lastLevel
+	}
+	f.lastLevel = level
+	entry.Level = logLevelNames[level]
+
+	if f.minLevel != logLevelUnknown && level < f.minLevel {
+		return nil, false
+	}
+	if f.exclude != nil && f.exclude.MatchString(line) {
+		return nil, false
+	}
+	if f.include != nil && !f.include.MatchString(line) {
+		return nil, false
+	}
+	if f.match != nil && !f.match.MatchString(line) {
+		return nil, false
+	}
+	if f.structured {
+		entry.Highlights = highlightRanges(entry.Message, f.include, f.match)
+	}
+	return entry, true
+}
+
+// highlightRanges returns the sorted and merged byte ranges of the message
+// matched by the patterns. They are offsets into the returned message, not
+// the raw line, which may carry a timestamp or the JSON around the message.
+func highlightRanges(message string, patterns ...*regexp.Regexp) [][2]int {
+	var ranges [][2]int
+	for _, pattern := range patterns {
+		if pattern == nil {
+			continue
+		}
+		for _, m := range pattern.FindAllStringIndex(message, -1) {
+			if m[0] < m[1] {
+				ranges = append(ranges, [2]int{m[0], m[1]})
+			}
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0] < ranges[j][0]
+	})
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+func jsonLevel(fields map[string]any) int {
+	for _, key := range jsonLevelKeys {
+		switch v := fields[key].(type) {
+		case string:
+			if level, ok := logLevelAliases[strings.ToLower(v)]; ok {
+				return level
+			}
+		case float64:
+			// bunyan/pino numeric levels: 10 trace ... 60 fatal
+			if v >= 10 && v <= 60 {
+				return int(v) / 10
+			}
+		}
+	}
+	return logLevelUnknown
+}
+
+func textLevel(message string) int {
+	if m := klogLevelPattern.FindStringSubmatch(message); m != nil {
+		return map[string]int{"I": logLevelInfo, "W": logLevelWarn, "E": logLevelError, "F": logLevelFatal}[m[1]]
+	}
+	if m := textLevelPattern.FindStringSubmatch(message); m != nil {
+		return logLevelAliases[strings.ToLower(m[1])]
+	}
+	return logLevelUnknown
+}
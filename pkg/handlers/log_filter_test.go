@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogFilter(t *testing.T, query string) *logFilter {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/logs/default/web?"+query, nil)
+	f, err := parseLogFilter(c)
+	require.NoError(t, err)
+	return f
+}
+
+func TestLogLevelDetection(t *testing.T) {
+	tests := []struct {
+		message string
+		level   string
+	}{
+		{`{"level":"warning","msg":"disk almost full"}`, "warn"},
+		{`{"severity":"ERROR","message":"boom"}`, "error"},
+		{`{"level":30,"msg":"pino"}`, "info"},
+		{`time="2025-06-01" level=debug msg="cache miss"`, "debug"},
+		{`[ERROR] connection refused`, "error"},
+		{`2025/06/01 10:00:00 INFO starting server`, "info"},
+		{`E0601 10:00:00.000000       1 controller.go:42] sync failed`, "error"},
+		{`nothing to see here`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			entry, ok := newTestLogFilter(t, "").apply(tt.message)
+			require.True(t, ok)
+			assert.Equal(t, tt.level, entry.Level)
+		})
+	}
+}
+
+func TestLogFilterApply(t *testing.T) {
+	lines := []string{
+		"2025-06-01T10:00:00Z INFO GET /healthz 200",
+		"2025-06-01T10:00:01Z ERROR request failed: Timeout",
+		"2025-06-01T10:00:01Z     at handler.go:12",
+		"2025-06-01T10:00:02Z WARN slow request /api/users",
+	}
+	filtered := func(query string) []string {
+		f := newTestLogFilter(t, query)
+		var out []string
+		for _, line := range lines {
+			if _, ok := f.apply(line); ok {
+				out = append(out, line)
+			}
+		}
+		return out
+	}
+
+	assert.Equal(t, lines[1:], filtered("level=warn"))
+	assert.Equal(t, lines[1:3], filtered("level=error"))
+	assert.Equal(t, []string{lines[1]}, filtered("match=timeout"))
+	assert.Equal(t, []string{lines[0], lines[3]}, filtered("include=%2F(healthz%7Capi)"))
+	assert.Equal(t, lines[1:], filtered("exclude=healthz"))
+
+	// Highlights are offsets into the message, without the timestamp
+	entry, ok := newTestLogFilter(t, "format=json&match=timeout").apply(lines[1])
+	require.True(t, ok)
+	assert.Equal(t, [][2]int{{22, 29}}, entry.Highlights)
+	assert.Equal(t, "Timeout", entry.Message[22:29])
+
+	// Include and match ranges are sorted and merged
+	entry, ok = newTestLogFilter(t, "format=json&match=request+failed&include=fail").apply(lines[1])
+	require.True(t, ok)
+	assert.Equal(t, [][2]int{{6, 20}}, entry.Highlights)
+
+	// Lowercasing İ changes its length, offsets must not shift
+	entry, ok = newTestLogFilter(t, "format=json&match=TIMEOUT").apply("İİ timeout")
+	require.True(t, ok)
+	require.Len(t, entry.Highlights, 1)
+	assert.Equal(t, "timeout", entry.Message[entry.Highlights[0][0]:entry.Highlights[0][1]])
+}
+
+func TestLogFilterStructured(t *testing.T) {
+	f := newTestLogFilter(t, "format=json")
+	entry, ok := f.apply(`2025-06-01T10:00:00Z {"level":"info","msg":"started","port":8080}`)
+	require.True(t, ok)
+	assert.Equal(t, "2025-06-01T10:00:00Z", entry.Timestamp)
+	assert.Equal(t, "info", entry.Level)
+	assert.Equal(t, "started", entry.Message)
+	assert.Equal(t, float64(8080), entry.Fields["port"])
+
+	f = newTestLogFilter(t, "format=json&match=START")
+	entry, ok = f.apply(`2025-06-01T10:00:00Z {"level":"info","msg":"started","port":8080}`)
+	require.True(t, ok)
+	assert.Equal(t, [][2]int{{0, 5}}, entry.Highlights)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/logs/default/web?include=%28", nil)
+	_, err := parseLogFilter(c)
+	assert.Error(t, err)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/zxh326/kite/pkg/cluster"
+)
+
+// DownloadPodLogs handles GET /logs/:namespace/:podName/download. It returns
+// the complete log of a container as a text file, tailLines is not applied
+// unless given explicitly.
+func (h *LogsHandler) DownloadPodLogs(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	namespace := c.Param("namespace")
+	podName := c.Param("podName")
+
+	logOptions, err := parseLogOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("tailLines") == "" {
+		logOptions.TailLines = nil
+	}
+	logOptions.Follow = false
+	logOptions.Timestamps = c.DefaultQuery("timestamps", "false") == "true"
+
+	if logOptions.Container == "" {
+		var pod corev1.Pod
+		if err := cs.K8sClient.Get(c.Request.Context(), types.NamespacedName{Namespace: namespace, Name: podName}, &pod); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logOptions.Container = pod.Spec.Containers[0].Name
+	}
+
+	stream, err := cs.K8sClient.ClientSet.CoreV1().Pods(namespace).GetLogs(podName, logOptions).Stream(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get pod logs: %v", err)})
+		return
+	}
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	filename := fmt.Sprintf("%s_%s", podName, logOptions.Container)
+	if logOptions.Previous {
+		filename += "_previous"
+	}
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.log"`, filename))
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, stream); err != nil {
+		klog.Warningf("Failed to write logs of %s/%s: %v", namespace, podName, err)
+	}
+}
+
+// DownloadPodLogsBundle handles GET /logs/:namespace/:podName/bundle, a
+// tar.gz with the current and previous logs of every container of the pod
+func (h *LogsHandler) DownloadPodLogsBundle(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	namespace := c.Param("namespace")
+	podName := c.Param("podName")
+
+	var pod corev1.Pod
+	if err := cs.K8sClient.Get(c.Request.Context(), types.NamespacedName{Namespace: namespace, Name: podName}, &pod); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	writeLogsBundle(c, cs, podName, []corev1.Pod{pod})
+}
+
+// DownloadWorkloadLogsBundle handles
+// GET /workload-logs/:resource/:namespace/:name/bundle, a tar.gz with the
+// current and previous logs of every container of the workload's pods
+func (h *LogsHandler) DownloadWorkloadLogsBundle(c *gin.Context) {
+	resource, namespace, name := c.Param("resource"), c.Param("namespace"), c.Param("name")
+	selector, err := workloadSelector(c, resource, namespace, name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	pods, err := listWorkloadPods(c.Request.Context(), cs, namespace, selector)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	writeLogsBundle(c, cs, resource+"_"+name, pods)
+}
+
+// bundleEntry is one log file of a bundle
+type bundleEntry struct {
+	pod       string
+	container string
+	previous  bool
+}
+
+func (e bundleEntry) path() string {
+	name := e.container + ".log"
+	if e.previous {
+		name = e.container + ".previous.log"
+	}
+	return e.pod + "/" + name
+}
+
+// bundleEntries lists the logs to collect, previous logs only exist for
+// containers that restarted
+func bundleEntries(pods []corev1.Pod) []bundleEntry {
+	var entries []bundleEntry
+	for _, pod := range pods {
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			if status.State.Waiting == nil || status.LastTerminationState.Terminated != nil {
+				entries = append(entries, bundleEntry{pod: pod.Name, container: status.Name})
+			}
+			if status.RestartCount > 0 {
+				entries = append(entries, bundleEntry{pod: pod.Name, container: status.Name, previous: true})
+			}
+		}
+	}
+	return entries
+}
+
+// writeLogsBundle streams the archive. Each log is spooled to a temporary
+// file first since tar headers need the size. Logs that can't be read are
+// listed in errors.txt rather than failing the whole download.
+func writeLogsBundle(c *gin.Context, cs *cluster.ClientSet, name string, pods []corev1.Pod) {
+	entries := bundleEntries(pods)
+	if len(entries) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no container logs found"})
+		return
+	}
+
+	now := time.Now()
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_logs_%s.tar.gz"`, name, now.Format("20060102-150405")))
+	c.Status(http.StatusOK)
+
+	gz := gzip.NewWriter(c.Writer)
+	tw := tar.NewWriter(gz)
+	defer func() {
+		_ = tw.Close()
+		_ = gz.Close()
+	}()
+
+	var failures []string
+	for _, entry := range entries {
+		if c.Request.Context().Err() != nil {
+			return
+		}
+		if err := addLogToBundle(c.Request.Context(), tw, cs, pods[0].Namespace, entry, now); err != nil {
+			if isBundleWriteError(err) {
+				return
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", entry.path(), err))
+		}
+	}
+	if len(failures) > 0 {
+		content := strings.Join(failures, "\n") + "\n"
+		if err := tw.WriteHeader(&tar.Header{Name: "errors.txt", Mode: 0o644, Size: int64(len(content)), ModTime: now}); err == nil {
+			_, _ = tw.Write([]byte(content))
+		}
+	}
+}
+
+// bundleWriteError marks failures writing the archive, the client is gone
+// and there is no point in reading further logs
+type bundleWriteError struct{ error }
+
+func isBundleWriteError(err error) bool {
+	_, ok := err.(bundleWriteError)
+	return ok
+}
+
+func addLogToBundle(ctx context.Context, tw *tar.Writer, cs *cluster.ClientSet, namespace string, entry bundleEntry, modTime time.Time) error {
+	stream, err := cs.K8sClient.ClientSet.CoreV1().Pods(namespace).GetLogs(entry.pod, &corev1.PodLogOptions{
+		Container: entry.container,
+		Previous:  entry.previous,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	spool, err := os.CreateTemp("", "kite-logs-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+	size, err := io.Copy(spool, stream)
+	if err != nil {
+		return err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: entry.path(), Mode: 0o644, Size: size, ModTime: modTime}); err != nil {
+		return bundleWriteError{err}
+	}
+	if _, err := io.Copy(tw, spool); err != nil {
+		return bundleWriteError{err}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,6 +23,32 @@ func NewLogsHandler() *LogsHandler {
 	return &LogsHandler{}
 }
 
+// parseLogOptions reads the log query parameters shared by the pod and
+// workload log endpoints
+func parseLogOptions(c *gin.Context) (*corev1.PodLogOptions, error) {
+	tail, err := strconv.ParseInt(c.DefaultQuery("tailLines", "100"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tailLines parameter")
+	}
+
+	logOptions := &corev1.PodLogOptions{
+		Container:  c.Query("container"),
+		Follow:     c.DefaultQuery("follow", "false") == "true",
+		Timestamps: c.DefaultQuery("timestamps", "true") == "true",
+		TailLines:  &tail,
+		Previous:   c.DefaultQuery("previous", "false") == "true",
+	}
+
+	if sinceSeconds := c.Query("sinceSeconds"); sinceSeconds != "" {
+		since, err := strconv.ParseInt(sinceSeconds, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sinceSeconds parameter")
+		}
+		logOptions.SinceSeconds = &since
+	}
+	return logOptions, nil
+}
+
 // GetPodLogs handles fetching logs for a specific pod/container
 func (h *LogsHandler) GetPodLogs(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -37,42 +64,18 @@ func (h *LogsHandler) GetPodLogs(c *gin.Context) {
 		return
 	}
 
-	// Get query parameters
-	container := c.Query("container")
-	tailLines := c.DefaultQuery("tailLines", "100")
-	follow := c.DefaultQuery("follow", "false")
-	timestamps := c.DefaultQuery("timestamps", "true")
-	previous := c.DefaultQuery("previous", "false")
-	sinceSeconds := c.Query("sinceSeconds")
-
-	// Parse parameters
-	tail, err := strconv.ParseInt(tailLines, 10, 64)
+	logOptions, err := parseLogOptions(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tailLines parameter"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	followBool := follow == "true"
-	timestampsBool := timestamps == "true"
-	previousBool := previous == "true"
-
-	// Build log options
-	logOptions := &corev1.PodLogOptions{
-		Container:  container,
-		Follow:     followBool,
-		Timestamps: timestampsBool,
-		TailLines:  &tail,
-		Previous:   previousBool,
-	}
-
-	if sinceSeconds != "" {
-		since, err := strconv.ParseInt(sinceSeconds, 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sinceSeconds parameter"})
-			return
-		}
-		logOptions.SinceSeconds = &since
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	container := logOptions.Container
+	followBool := logOptions.Follow
 
 	// Get log stream
 	req := cs.K8sClient.ClientSet.CoreV1().Pods(namespace).GetLogs(podName, logOptions)
@@ -102,6 +105,16 @@ func (h *LogsHandler) GetPodLogs(c *gin.Context) {
 
 		for scanner.Scan() {
 			line := scanner.Text()
+			if filter.active() {
+				entry, ok := filter.apply(line)
+				if !ok {
+					continue
+				}
+				if filter.structured {
+					data, _ := json.Marshal(entry)
+					line = string(data)
+				}
+			}
 			sseData := fmt.Sprintf("event: log\ndata: %s\n\n", line)
 			if _, err := c.Writer.WriteString(sseData); err != nil {
 				return
@@ -131,11 +144,26 @@ func (h *LogsHandler) GetPodLogs(c *gin.Context) {
 			logLines = logLines[:len(logLines)-1]
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"logs":      logLines,
+		response := gin.H{
 			"container": container,
 			"pod":       podName,
 			"namespace": namespace,
-		})
+		}
+		if filter.active() {
+			filtered := make([]string, 0, len(logLines))
+			entries := make([]*logEntry, 0, len(logLines))
+			for _, line := range logLines {
+				if entry, ok := filter.apply(line); ok {
+					filtered = append(filtered, line)
+					entries = append(entries, entry)
+				}
+			}
+			logLines = filtered
+			if filter.structured {
+				response["entries"] = entries
+			}
+		}
+		response["logs"] = logLines
+		c.JSON(http.StatusOK, response)
 	}
 }
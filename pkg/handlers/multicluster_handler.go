@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/handlers/resources"
+	"github.com/zxh326/kite/pkg/middleware"
+	"github.com/zxh326/kite/pkg/rbac"
+)
+
+// multiClusterTimeout bounds every cluster, so a slow one doesn't hold up the others
+const multiClusterTimeout = 15 * time.Second
+
+// MultiClusterHandler runs lists and searches across several clusters. Each
+// cluster is authorized and impersonated on its own, failures are reported
+// per cluster next to the results of the others.
+type MultiClusterHandler struct {
+	cm *cluster.ClusterManager
+}
+
+type MultiClusterListResponse struct {
+	// Items are the objects of all clusters, newest first, with a cluster field
+	Items  []map[string]any      `json:"items"`
+	Errors []common.ClusterError `json:"errors,omitempty"`
+}
+
+type MultiClusterSearchResponse struct {
+	Results []common.SearchResult `json:"results"`
+	Total   int                   `json:"total"`
+	Errors  []common.ClusterError `json:"errors,omitempty"`
+}
+
+func NewMultiClusterHandler(cm *cluster.ClusterManager) *MultiClusterHandler {
+	return &MultiClusterHandler{cm: cm}
+}
+
+// targets resolves the clusters parameter, a comma separated list of names,
+// to the clusters the user may access with req. All clusters are used when it
+// is empty, those without access are left out instead of reported.
+func (h *MultiClusterHandler) targets(c *gin.Context, req rbac.Request) (map[string]*cluster.ClientSet, []common.ClusterError) {
+	all := h.cm.ClientSets()
+	var subject rbac.Subject
+	if user, ok := c.Get("user"); ok {
+		subject = rbac.SubjectFromUser(user.(gin.H))
+	}
+
+	var names []string
+	explicit := c.Query("clusters") != ""
+	if explicit {
+		for _, name := range strings.Split(c.Query("clusters"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for name := range all {
+			names = append(names, name)
+		}
+	}
+
+	targets := make(map[string]*cluster.ClientSet, len(names))
+	var errs []common.ClusterError
+	for _, name := range names {
+		cs, ok := all[name]
+		if !ok {
+			errs = append(errs, common.ClusterError{Cluster: name, Error: "cluster not found"})
+			continue
+		}
+		req.Cluster = cs.Name
+		if !rbac.Authorize(subject, req) {
+			if explicit {
+				errs = append(errs, common.ClusterError{Cluster: name, Error: "permission denied, this action requires the " + string(req.Role) + " role"})
+			}
+			continue
+		}
+		if cs.Health().Status == common.ClusterStatusUnreachable {
+			errs = append(errs, common.ClusterError{Cluster: name, Error: "cluster is unreachable"})
+			continue
+		}
+		targets[name] = cs
+	}
+	return targets, errs
+}
+
+// fanOut calls fn for every target concurrently. fn gets a copy of the request
+// context bound to the cluster, like ClusterMiddleware prepares it.
+func fanOut(c *gin.Context, targets map[string]*cluster.ClientSet, fn func(cc *gin.Context, name string) error) []common.ClusterError {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []common.ClusterError
+	)
+	for name, cs := range targets {
+		wg.Add(1)
+		go func(name string, cs *cluster.ClientSet) {
+			defer wg.Done()
+			err := func() error {
+				ctx, cancel := context.WithTimeout(c.Request.Context(), multiClusterTimeout)
+				defer cancel()
+				cc := c.Copy()
+				cc.Request = c.Request.WithContext(ctx)
+				cs, err := middleware.ClientSetForRequest(cc, cs)
+				if err != nil {
+					return err
+				}
+				cc.Set(middleware.ClusterNameKey, cs.Name)
+				cc.Set("cluster", cs)
+				if err := fn(cc, name); err != nil {
+					return err
+				}
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return errors.New("timed out")
+				}
+				return nil
+			}()
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, common.ClusterError{Cluster: name, Error: err.Error()})
+				mu.Unlock()
+			}
+		}(name, cs)
+	}
+	wg.Wait()
+	return errs
+}
+
+func sortClusterErrors(errs []common.ClusterError) {
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Cluster < errs[j].Cluster
+	})
+}
+
+// List handles GET /multicluster/:resource and /multicluster/:resource/:namespace,
+// it takes the labelSelector, fieldSelector and limit (per cluster) parameters of List
+func (h *MultiClusterHandler) List(c *gin.Context) {
+	list, err := resources.NewClusterList(c, c.Param("resource"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targets, errs := h.targets(c, rbac.Request{Namespace: list.Namespace(), Role: rbac.RoleViewer})
+
+	type clusterObject struct {
+		cluster string
+		object  client.Object
+	}
+	var (
+		mu      sync.Mutex
+		objects []clusterObject
+	)
+	errs = append(errs, fanOut(c, targets, func(cc *gin.Context, name string) error {
+		result, err := list.Run(cc.Request.Context(), cc.MustGet("cluster").(*cluster.ClientSet))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		for _, obj := range result {
+			objects = append(objects, clusterObject{cluster: name, object: obj})
+		}
+		mu.Unlock()
+		return nil
+	})...)
+	sortClusterErrors(errs)
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		t1, t2 := objects[i].object.GetCreationTimestamp(), objects[j].object.GetCreationTimestamp()
+		if !t1.Equal(&t2) {
+			return t1.After(t2.Time)
+		}
+		if objects[i].cluster != objects[j].cluster {
+			return objects[i].cluster < objects[j].cluster
+		}
+		return objects[i].object.GetName() < objects[j].object.GetName()
+	})
+	items := make([]map[string]any, 0, len(objects))
+	for _, o := range objects {
+		item, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o.object)
+		if err != nil {
+			errs = append(errs, common.ClusterError{Cluster: o.cluster, Error: err.Error()})
+			continue
+		}
+		item["cluster"] = o.cluster
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, MultiClusterListResponse{Items: items, Errors: errs})
+}
+
+// Search handles GET /multicluster/search, the global search run in every
+// cluster with the cluster set on each result
+func (h *MultiClusterHandler) Search(c *gin.Context) {
+	query := c.Query("q")
+	if len(query) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query must be at least 2 characters long"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit > 100 {
+		limit = 50
+	}
+	targets, errs := h.targets(c, rbac.Request{AnyNamespace: true, Role: rbac.RoleViewer})
+
+	var (
+		mu      sync.Mutex
+		results []common.SearchResult
+	)
+	errs = append(errs, fanOut(c, targets, func(cc *gin.Context, name string) error {
+		clusterResults := searchCluster(cc, query, limit)
+		mu.Lock()
+		for _, result := range clusterResults {
+			result.Cluster = name
+			results = append(results, result)
+		}
+		mu.Unlock()
+		return nil
+	})...)
+	sortClusterErrors(errs)
+
+	sortResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	c.JSON(http.StatusOK, MultiClusterSearchResponse{Results: results, Total: len(results), Errors: errs})
+}
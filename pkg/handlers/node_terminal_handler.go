@@ -15,6 +15,7 @@ import (
 	"github.com/zxh326/kite/pkg/cluster"
 	"github.com/zxh326/kite/pkg/common"
 	"github.com/zxh326/kite/pkg/kube"
+	"github.com/zxh326/kite/pkg/recording"
 	"github.com/zxh326/kite/pkg/utils"
 
 	corev1 "k8s.io/api/core/v1"
@@ -78,6 +79,10 @@ func (h *NodeTerminalHandler) HandleNodeTerminalWebSocket(c *gin.Context) {
 		}
 
 		session := kube.NewTerminalSession(cs.K8sClient, conn, "kube-system", nodeAgentName, common.NodeTerminalPodName)
+		defer recordSession(c, session, &recording.Recording{
+			Kind: recording.KindNode,
+			Node: nodeName,
+		})()
 		if err := session.Start(ctx, "attach"); err != nil {
 			klog.Errorf("Terminal session error: %v", err)
 		}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/rbac"
+)
+
+// GetPermissions returns the role bindings of the current user in the selected cluster
+func GetPermissions(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+
+	if !rbac.Enabled() {
+		// Without a RBAC config every user has full access, unless Kite is read-only
+		role := rbac.RoleAdmin
+		if common.Readonly {
+			role = rbac.RoleViewer
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"enabled":  false,
+			"bindings": []rbac.Binding{{Role: role}},
+		})
+		return
+	}
+
+	user := c.MustGet("user").(gin.H)
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  true,
+		"bindings": rbac.Current().BindingsFor(rbac.SubjectFromUser(user), cs.Name),
+	})
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+
+	"github.com/zxh326/kite/pkg/audit"
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+	"github.com/zxh326/kite/pkg/middleware"
+	"github.com/zxh326/kite/pkg/recording"
+)
+
+const (
+	defaultRecordingLimit = 100
+	maxRecordingLimit     = 1000
+)
+
+// recordSession starts recording a terminal session. It fills in the user and
+// cluster, links the recording to the audit entry of the request and returns
+// a function that finishes the recording. Sessions are still served when the
+// recording can't be started.
+func recordSession(c *gin.Context, session *kube.TerminalSession, rec *recording.Recording) func() {
+	rec.User, _ = middleware.RequestUser(c)
+	rec.Cluster = c.GetString(middleware.ClusterNameKey)
+	recorder, err := recording.Start(rec)
+	if err != nil {
+		klog.Errorf("Failed to record terminal session on %s: %v", rec.Target(), err)
+		return func() {}
+	}
+	if recorder == nil {
+		return func() {}
+	}
+	session.SetRecorder(recorder)
+	if entry, ok := audit.FromContext(c); ok {
+		entry.Request += " recording=" + recorder.ID()
+	}
+	return func() {
+		if err := recorder.Close(); err != nil {
+			klog.Errorf("Failed to save recording %s: %v", recorder.ID(), err)
+		}
+	}
+}
+
+// ListRecordings lists the terminal recordings of the selected cluster,
+// newest first
+func ListRecordings(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+
+	filter := recording.Filter{
+		Cluster: cs.Name,
+		User:    c.Query("user"),
+		Kind:    c.Query("kind"),
+		Limit:   defaultRecordingLimit,
+	}
+	if v := c.Query("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter, expected RFC3339 time"})
+			return
+		}
+		filter.Since = since
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+		filter.Limit = min(limit, maxRecordingLimit)
+	}
+
+	recordings, err := recording.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list recordings: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items":   recordings,
+		"enabled": recording.Enabled(),
+	})
+}
+
+// clusterRecording returns the recording of the id parameter, recordings of
+// other clusters are reported as missing
+func clusterRecording(c *gin.Context) (*recording.Recording, bool) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	rec, err := recording.Get(c.Param("id"))
+	if errors.Is(err, recording.ErrNotFound) || (err == nil && rec.Cluster != cs.Name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recording not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return rec, true
+}
+
+// GetRecording returns the metadata of a recording
+func GetRecording(c *gin.Context) {
+	if rec, ok := clusterRecording(c); ok {
+		c.JSON(http.StatusOK, rec)
+	}
+}
+
+// GetRecordingCast returns the asciicast v2 stream of a recording for replay,
+// with download=true as a file
+func GetRecordingCast(c *gin.Context) {
+	rec, ok := clusterRecording(c)
+	if !ok {
+		return
+	}
+	cast, err := recording.Open(rec.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		_ = cast.Close()
+	}()
+
+	c.Header("Content-Type", "application/x-asciicast")
+	if c.Query("download") == "true" {
+		c.Header("Content-Disposition", `attachment; filename="`+rec.ID+`.cast"`)
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, cast); err != nil {
+		klog.Warningf("Failed to write recording %s: %v", rec.ID, err)
+	}
+}
+
+// DeleteRecording removes a recording
+func DeleteRecording(c *gin.Context) {
+	rec, ok := clusterRecording(c)
+	if !ok {
+		return
+	}
+	if err := recording.Delete(rec.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "recording deleted"})
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zxh326/kite/pkg/audit"
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+	"github.com/zxh326/kite/pkg/middleware"
+	"github.com/zxh326/kite/pkg/recording"
+)
+
+func useMemoryRecordings(t *testing.T) {
+	t.Helper()
+	require.NoError(t, recording.Init("memory", "", recording.Retention{}))
+	t.Cleanup(func() {
+		require.NoError(t, recording.Init("memory", "", recording.Retention{}))
+	})
+}
+
+func TestRecordSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useMemoryRecordings(t)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("user", gin.H{"username": "alice"})
+	c.Set(middleware.ClusterNameKey, "prod")
+	entry := &audit.Entry{Request: "GET /terminal/default/web-1/ws"}
+	audit.NewContext(c, entry)
+	session := kube.NewTerminalSession(nil, nil, "default", "web-1", "app")
+
+	finish := recordSession(c, session, &recording.Recording{Kind: recording.KindPod, Namespace: "default", Pod: "web-1", Container: "app"})
+	recordings, err := recording.List(recording.Filter{})
+	require.NoError(t, err)
+	require.Len(t, recordings, 1)
+	rec := recordings[0]
+	assert.Equal(t, "alice", rec.User)
+	assert.Equal(t, "prod", rec.Cluster)
+	assert.True(t, rec.EndedAt.IsZero())
+	assert.Equal(t, "GET /terminal/default/web-1/ws recording="+rec.ID, entry.Request)
+
+	finish()
+	got, err := recording.Get(rec.ID)
+	require.NoError(t, err)
+	assert.False(t, got.EndedAt.IsZero())
+
+	// Sessions are served without a recording when recording is disabled
+	require.NoError(t, recording.Init("none", "", recording.Retention{}))
+	recordSession(c, session, &recording.Recording{Kind: recording.KindPod})()
+}
+
+func TestRecordingHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useMemoryRecordings(t)
+	start := func(clusterName string) string {
+		recorder, err := recording.Start(&recording.Recording{User: "alice", Cluster: clusterName, Kind: recording.KindNode, Node: "node-1"})
+		require.NoError(t, err)
+		recorder.Output([]byte("hello\r\n"))
+		require.NoError(t, recorder.Close())
+		return recorder.ID()
+	}
+	prod, staging := start("prod"), start("staging")
+
+	serve := func(handler gin.HandlerFunc, method, target, id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, target, nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		c.Set("cluster", &cluster.ClientSet{Name: "prod"})
+		handler(c)
+		return w
+	}
+
+	w := serve(ListRecordings, http.MethodGet, "/recordings", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Items   []recording.Recording `json:"items"`
+		Enabled bool                  `json:"enabled"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.True(t, list.Enabled)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, prod, list.Items[0].ID)
+	assert.Equal(t, http.StatusBadRequest, serve(ListRecordings, http.MethodGet, "/recordings?since=yesterday", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(ListRecordings, http.MethodGet, "/recordings?limit=-1", "").Code)
+
+	assert.Equal(t, http.StatusOK, serve(GetRecording, http.MethodGet, "/recordings/"+prod, prod).Code)
+	assert.Equal(t, http.StatusNotFound, serve(GetRecording, http.MethodGet, "/recordings/"+staging, staging).Code, "recordings of other clusters are hidden")
+	assert.Equal(t, http.StatusNotFound, serve(GetRecording, http.MethodGet, "/recordings/missing", "missing").Code)
+
+	w = serve(GetRecordingCast, http.MethodGet, "/recordings/"+prod+"/cast?download=true", prod)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-asciicast", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), prod+".cast")
+	assert.Contains(t, w.Body.String(), `"version":2`)
+	assert.Contains(t, w.Body.String(), `"o","hello\r\n"`)
+
+	assert.Equal(t, http.StatusNotFound, serve(DeleteRecording, http.MethodDelete, "/recordings/"+staging, staging).Code)
+	assert.Equal(t, http.StatusOK, serve(DeleteRecording, http.MethodDelete, "/recordings/"+prod, prod).Code)
+	_, err := recording.Get(prod)
+	assert.ErrorIs(t, err, recording.ErrNotFound)
+	_, err = recording.Get(staging)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/handlers/resources"
+	"github.com/zxh326/kite/pkg/webhook"
+)
+
+// maxRegistryPayload bounds the size of registry notifications
+const maxRegistryPayload = 1 << 20
+
+// Values of the registry update annotation, restart is the default
+const (
+	registryUpdateRestart  = "restart"
+	registryUpdateDigest   = "digest"
+	registryUpdateDisabled = "disabled"
+)
+
+// registryUpdateResult reports what was done for a container running a
+// pushed image
+type registryUpdateResult struct {
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	// Pushed is the normalized pushed image the container matched
+	Pushed        string        `json:"pushed"`
+	Action        common.Action `json:"action,omitempty"`
+	PreviousImage string        `json:"previousImage,omitempty"`
+	Image         string        `json:"image,omitempty"`
+	Skipped       string        `json:"skipped,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// HandleRegistryWebhook receives push notifications of Docker Hub, Harbor
+// or OCI registries and rolls out the workloads running the pushed
+// repository and tag. Workloads are restarted so pods pull the tag again,
+// with the digest mode of the registry update annotation their image is
+// pinned to the pushed digest instead.
+func (h *WebhookHandler) HandleRegistryWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRegistryPayload))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Failed to read request body: " + err.Error(),
+		})
+		return
+	}
+	pushes, err := webhook.ParseRegistryPush(c.Param("format"), body, c.Query("registry"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	results := []registryUpdateResult{}
+	if len(pushes) == 0 {
+		c.JSON(200, gin.H{
+			"message": "No tagged image was pushed",
+			"results": results,
+		})
+		return
+	}
+
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	namespaces := []string{""}
+	if token, ok := webhook.FromContext(c); ok && len(token.Namespaces) > 0 {
+		namespaces = token.Namespaces
+	}
+	var containers []resources.WorkloadContainer
+	for _, namespace := range namespaces {
+		found, err := resources.ListWorkloadContainers(c.Request.Context(), cs, namespace)
+		if err != nil {
+			c.JSON(500, gin.H{
+				"error": "Failed to list workloads: " + err.Error(),
+			})
+			return
+		}
+		containers = append(containers, found...)
+	}
+
+	restarted := map[string]bool{}
+	failed := 0
+	for _, push := range pushes {
+		for _, container := range containers {
+			if !push.Matches(container.Image) {
+				continue
+			}
+			result := h.applyRegistryPush(c, cs, push, container, restarted)
+			if result.Error != "" {
+				failed++
+			}
+			results = append(results, result)
+		}
+	}
+	klog.Infof("Registry webhook %s: %d image(s) pushed, %d container(s) matched, %d failed", c.Param("format"), len(pushes), len(results), failed)
+
+	status := 200
+	if failed > 0 {
+		status = 500
+	}
+	c.JSON(status, gin.H{
+		"message": fmt.Sprintf("%d container(s) matched, %d failed", len(results), failed),
+		"images":  pushes,
+		"results": results,
+	})
+}
+
+func (h *WebhookHandler) applyRegistryPush(c *gin.Context, cs *cluster.ClientSet, push webhook.ImageRef, container resources.WorkloadContainer, restarted map[string]bool) registryUpdateResult {
+	result := registryUpdateResult{
+		Resource:  container.Resource,
+		Namespace: container.Namespace,
+		Name:      container.Name,
+		Container: container.Container,
+		Pushed:    push.String(),
+	}
+
+	mode, skipped := registryUpdateMode(container.Annotations)
+	if skipped != "" {
+		result.Skipped = skipped
+		return result
+	}
+	result.Action, result.Skipped = registryUpdateAction(mode, push, container)
+	if result.Skipped != "" {
+		return result
+	}
+	if err := authorizeWebhook(c, cs, container.Namespace, container.Resource, result.Action); err != nil {
+		result.Action = ""
+		result.Skipped = err.Error()
+		return result
+	}
+
+	if result.Action == common.ActionUpdateImage {
+		update, err := resources.UpdateImage(c.Request.Context(), cs, container.Resource, container.Namespace, container.Name,
+			container.Container, webhook.WithDigest(container.Image, push.Digest))
+		if err != nil {
+			result.Error = "Failed to update image: " + err.Error()
+			return result
+		}
+		result.PreviousImage, result.Image = update.PreviousImage, update.Image
+		return result
+	}
+
+	// Several containers of a workload may run the pushed image
+	key := container.Resource + "/" + container.Namespace + "/" + container.Name
+	if restarted[key] {
+		return result
+	}
+	if err := restartWorkload(c, container.Resource, container.Namespace, container.Name); err != nil {
+		result.Error = "Failed to restart resource: " + err.Error()
+		return result
+	}
+	restarted[key] = true
+	return result
+}
+
+// registryUpdateMode returns how a workload follows pushes of its images
+// from its annotations, or why it doesn't. Workloads without the annotation
+// are only updated when REGISTRY_WEBHOOK_REQUIRE_ANNOTATION is false.
+func registryUpdateMode(annotations map[string]string) (mode, skipped string) {
+	mode, annotated := annotations[common.RegistryUpdateAnnotation]
+	switch {
+	case !annotated && common.RegistryWebhookRequireAnnotation:
+		return "", "workload has no " + common.RegistryUpdateAnnotation + " annotation"
+	case mode == "", mode == "true":
+		return registryUpdateRestart, ""
+	case mode == "false", mode == registryUpdateDisabled:
+		return "", "disabled by the " + common.RegistryUpdateAnnotation + " annotation"
+	case mode != registryUpdateRestart && mode != registryUpdateDigest:
+		return "", fmt.Sprintf("unknown %s annotation value %q", common.RegistryUpdateAnnotation, mode)
+	}
+	return mode, ""
+}
+
+// registryUpdateAction returns the action that rolls a container out to a
+// pushed image, or why there is none. A restart only pulls the pushed image
+// when the container always pulls, other containers are pinned to the
+// pushed digest instead, or skipped when the notification has none.
+func registryUpdateAction(mode string, push webhook.ImageRef, container resources.WorkloadContainer) (common.Action, string) {
+	policy := pullPolicy(container)
+	if push.Digest != "" && (mode == registryUpdateDigest || policy != corev1.PullAlways) {
+		return common.ActionUpdateImage, ""
+	}
+	if policy != corev1.PullAlways {
+		// Docker Hub notifications carry no digest
+		return "", fmt.Sprintf("imagePullPolicy %s keeps the cached image on restart and the notification has no digest to pin", policy)
+	}
+	return common.ActionRestart, ""
+}
+
+// pullPolicy returns the pull policy of a container, an unset one defaults
+// like the API server does
+func pullPolicy(container resources.WorkloadContainer) corev1.PullPolicy {
+	if container.PullPolicy != "" {
+		return container.PullPolicy
+	}
+	if ref := webhook.ParseImageRef(container.Image); ref.Digest == "" && ref.Tag == "latest" {
+		return corev1.PullAlways
+	}
+	return corev1.PullIfNotPresent
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/handlers/resources"
+	"github.com/zxh326/kite/pkg/webhook"
+)
+
+func TestRegistryUpdateMode(t *testing.T) {
+	annotated := func(mode string) map[string]string {
+		return map[string]string{common.RegistryUpdateAnnotation: mode}
+	}
+
+	mode, skipped := registryUpdateMode(nil)
+	assert.Empty(t, mode)
+	assert.Contains(t, skipped, "no kite.io/registry-update annotation", "workloads opt in by default")
+
+	for value, expected := range map[string]string{"": registryUpdateRestart, "true": registryUpdateRestart, "restart": registryUpdateRestart, "digest": registryUpdateDigest} {
+		mode, skipped = registryUpdateMode(annotated(value))
+		assert.Equal(t, expected, mode, value)
+		assert.Empty(t, skipped, value)
+	}
+	for _, value := range []string{"false", "disabled", "always"} {
+		mode, skipped = registryUpdateMode(annotated(value))
+		assert.Empty(t, mode, value)
+		assert.NotEmpty(t, skipped, value)
+	}
+
+	common.RegistryWebhookRequireAnnotation = false
+	t.Cleanup(func() { common.RegistryWebhookRequireAnnotation = true })
+	mode, skipped = registryUpdateMode(map[string]string{"app": "web"})
+	assert.Equal(t, registryUpdateRestart, mode)
+	assert.Empty(t, skipped)
+}
+
+func TestRegistryUpdateAction(t *testing.T) {
+	tagged := webhook.ImageRef{Registry: "docker.io", Repository: "team/app", Tag: "v1"}
+	pinned := tagged
+	pinned.Digest = "sha256:abc"
+	container := func(image string, policy corev1.PullPolicy) resources.WorkloadContainer {
+		return resources.WorkloadContainer{Image: image, PullPolicy: policy}
+	}
+
+	action, skipped := registryUpdateAction(registryUpdateRestart, tagged, container("team/app:v1", corev1.PullAlways))
+	assert.Equal(t, common.ActionRestart, action)
+	assert.Empty(t, skipped)
+	action, _ = registryUpdateAction(registryUpdateDigest, pinned, container("team/app:v1", corev1.PullAlways))
+	assert.Equal(t, common.ActionUpdateImage, action)
+
+	// Restarting doesn't pull a mutable tag again unless the pull policy is Always
+	action, _ = registryUpdateAction(registryUpdateRestart, pinned, container("team/app:v1", corev1.PullIfNotPresent))
+	assert.Equal(t, common.ActionUpdateImage, action, "falls back to pinning the digest")
+	action, skipped = registryUpdateAction(registryUpdateRestart, tagged, container("team/app:v1", corev1.PullIfNotPresent))
+	assert.Empty(t, action)
+	assert.Contains(t, skipped, "imagePullPolicy IfNotPresent")
+	action, skipped = registryUpdateAction(registryUpdateRestart, tagged, container("team/app:v1", ""))
+	assert.Empty(t, action)
+	assert.Contains(t, skipped, "imagePullPolicy IfNotPresent", "tags other than latest default to IfNotPresent")
+	action, _ = registryUpdateAction(registryUpdateRestart, webhook.ImageRef{Registry: "docker.io", Repository: "team/app", Tag: "latest"}, container("team/app", ""))
+	assert.Equal(t, common.ActionRestart, action)
+}
@@ -1,16 +1,30 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+	"k8s.io/apimachinery/pkg/types"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/zxh326/kite/pkg/audit"
 	"github.com/zxh326/kite/pkg/cluster"
 )
 
+// FieldManager identifies Kite as the owner of applied fields
+const FieldManager = "kite"
+
 type ResourceApplyHandler struct {
 }
 
@@ -20,9 +34,171 @@ func NewResourceApplyHandler() *ResourceApplyHandler {
 
 type ApplyResourceRequest struct {
 	YAML string `json:"yaml" binding:"required"`
+	// Namespace is used for namespaced objects without a namespace, defaults to "default"
+	Namespace string `json:"namespace"`
+	DryRun    bool   `json:"dryRun"`
+	// Force takes ownership of fields managed by other field managers
+	Force bool `json:"force"`
+}
+
+// ApplyAction describes what applying an object did
+type ApplyAction string
+
+const (
+	ApplyActionCreated    ApplyAction = "created"
+	ApplyActionConfigured ApplyAction = "configured"
+	ApplyActionUnchanged  ApplyAction = "unchanged"
+)
+
+// ApplyResult is the outcome of applying a single object
+type ApplyResult struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Name       string      `json:"name"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Success    bool        `json:"success"`
+	Action     ApplyAction `json:"action,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Diff       string      `json:"diff,omitempty"`
+	// Object is the resulting object, only returned for dry-runs
+	Object *unstructured.Unstructured `json:"object,omitempty"`
+}
+
+// decodeManifests splits multi-document YAML, JSON objects, JSON arrays and
+// v1 List objects into individual objects
+func decodeManifests(data string) ([]*unstructured.Unstructured, error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(strings.NewReader(data), 4096)
+	var objects []*unstructured.Unstructured
+	for i := 1; ; i++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+
+		docs := []json.RawMessage{raw}
+		if raw[0] == '[' {
+			docs = nil
+			if err := json.Unmarshal(raw, &docs); err != nil {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+		}
+		for _, doc := range docs {
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(doc); err != nil {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+			if !obj.IsList() {
+				objects = append(objects, obj)
+				continue
+			}
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+			for j := range list.Items {
+				objects = append(objects, &list.Items[j])
+			}
+		}
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("no objects found")
+	}
+	return objects, nil
+}
+
+// diffObject strips server managed metadata so diffs only show meaningful changes
+func diffObject(obj *unstructured.Unstructured) any {
+	if obj == nil {
+		return nil
+	}
+	obj = obj.DeepCopy()
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	return obj
+}
+
+// applyObject server-side applies a single object
+func (h *ResourceApplyHandler) applyObject(c *gin.Context, cs *cluster.ClientSet, obj *unstructured.Unstructured, req *ApplyResourceRequest) ApplyResult {
+	ctx := c.Request.Context()
+	result := ApplyResult{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+	}
+	if obj.GetName() == "" {
+		result.Error = "metadata.name is required"
+		return result
+	}
+
+	gvk := obj.GroupVersionKind()
+	mapping, err := cs.K8sClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(req.Namespace)
+		}
+	} else {
+		obj.SetNamespace("")
+	}
+	result.Namespace = obj.GetNamespace()
+	obj.SetManagedFields(nil)
+
+	var before *unstructured.Unstructured
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(gvk)
+	if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, current); err == nil {
+		before = current
+	} else if !apierrors.IsNotFound(err) {
+		result.Error = err.Error()
+		return result
+	}
+
+	opts := []client.PatchOption{client.FieldOwner(FieldManager)}
+	if req.Force {
+		opts = append(opts, client.ForceOwnership)
+	}
+	if req.DryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	if err := cs.K8sClient.Patch(ctx, obj, client.Apply, opts...); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	diff, err := audit.Diff(diffObject(before), diffObject(obj))
+	if err != nil {
+		klog.Warningf("Failed to diff %s %s: %v", result.Kind, result.Name, err)
+	}
+	result.Success = true
+	result.Diff = diff
+	switch {
+	case before == nil:
+		result.Action = ApplyActionCreated
+	case diff == "":
+		result.Action = ApplyActionUnchanged
+	default:
+		result.Action = ApplyActionConfigured
+	}
+	if req.DryRun {
+		result.Object = obj
+	} else {
+		audit.RecordChange(c, diffObject(before), diffObject(obj))
+	}
+	return result
 }
 
-// ApplyResource applies a YAML resource to the cluster
+// ApplyResource server-side applies one or more YAML or JSON manifests to the cluster
 func (h *ResourceApplyHandler) ApplyResource(c *gin.Context) {
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
 
@@ -31,32 +207,59 @@ func (h *ResourceApplyHandler) ApplyResource(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Namespace == "" {
+		req.Namespace = "default"
+	}
+	if entry, ok := audit.FromContext(c); ok && req.DryRun {
+		entry.Request = "dryRun=true"
+	}
 
-	// Decode YAML into unstructured object
-	decodeUniversal := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
-	obj := &unstructured.Unstructured{}
-
-	_, _, err := decodeUniversal.Decode([]byte(req.YAML), nil, obj)
+	objects, err := decodeManifests(req.YAML)
 	if err != nil {
 		klog.Errorf("Failed to decode YAML: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid YAML format: " + err.Error()})
 		return
 	}
 
-	ctx := c.Request.Context()
+	results := make([]ApplyResult, 0, len(objects))
+	failed := 0
+	for _, obj := range objects {
+		result := h.applyObject(c, cs, obj, &req)
+		if !result.Success {
+			failed++
+			klog.Errorf("Failed to apply %s %s: %s", result.Kind, result.Name, result.Error)
+		} else if !req.DryRun {
+			klog.Infof("Successfully applied resource: %s/%s (%s)", result.Kind, result.Name, result.Action)
+		}
+		results = append(results, result)
+	}
 
-	// Try to create the resource
-	if err := cs.K8sClient.Create(ctx, obj); err != nil {
-		klog.Errorf("Failed to create resource: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create resource: " + err.Error()})
-		return
+	first := results[0]
+	if len(results) == 1 {
+		resource := strings.ToLower(first.Kind)
+		gvk := objects[0].GroupVersionKind()
+		if mapping, err := cs.K8sClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			resource = mapping.Resource.Resource
+		}
+		audit.SetTarget(c, resource, first.Namespace, first.Name)
 	}
 
-	klog.Infof("Successfully created resource: %s/%s", obj.GetKind(), obj.GetName())
-	c.JSON(http.StatusCreated, gin.H{
-		"message":   "Resource created successfully",
-		"kind":      obj.GetKind(),
-		"name":      obj.GetName(),
-		"namespace": obj.GetNamespace(),
-	})
+	response := gin.H{
+		"dryRun":    req.DryRun,
+		"results":   results,
+		"kind":      first.Kind,
+		"name":      first.Name,
+		"namespace": first.Namespace,
+	}
+	switch {
+	case failed == len(results):
+		response["error"] = fmt.Sprintf("Failed to apply %d of %d resources: %s", failed, len(results), first.Error)
+		c.JSON(http.StatusInternalServerError, response)
+	case failed > 0:
+		response["message"] = fmt.Sprintf("Applied %d of %d resources", len(results)-failed, len(results))
+		c.JSON(http.StatusMultiStatus, response)
+	default:
+		response["message"] = fmt.Sprintf("Applied %d resources successfully", len(results))
+		c.JSON(http.StatusOK, response)
+	}
 }
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name: "multi-document yaml",
+			data: `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+# comment only document
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: demo
+data:
+  replicas: "3"
+`,
+			expected: []string{"Namespace/demo", "ConfigMap/settings"},
+		},
+		{
+			name:     "json array",
+			data:     `[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}},{"apiVersion":"v1","kind":"Secret","metadata":{"name":"b"}}]`,
+			expected: []string{"ConfigMap/a", "Secret/b"},
+		},
+		{
+			name: "v1 list",
+			data: `
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: web
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: web
+`,
+			expected: []string{"Deployment/web", "Service/web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := decodeManifests(tt.data)
+			require.NoError(t, err)
+			names := make([]string, 0, len(objects))
+			for _, obj := range objects {
+				names = append(names, obj.GetKind()+"/"+obj.GetName())
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+
+	_, err := decodeManifests("---\n# nothing here\n")
+	assert.Error(t, err)
+}
@@ -221,6 +221,7 @@ func (h *AdmissionControllerHandler) Search(c *gin.Context, query string, limit
 					Name:         item.Name,
 					ResourceType: "admission-controllers",
 					CreatedAt:    item.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
+					Score:        scorePrefix,
 				})
 			}
 		}
@@ -236,6 +237,7 @@ func (h *AdmissionControllerHandler) Search(c *gin.Context, query string, limit
 					Name:         item.Name,
 					ResourceType: "admission-controllers",
 					CreatedAt:    item.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
+					Score:        scorePrefix,
 				})
 			}
 		}
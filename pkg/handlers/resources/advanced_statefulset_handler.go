@@ -112,7 +112,7 @@ func (h *AdvancedStatefulSetHandler) Restart(c *gin.Context, namespace, name str
 
 	result := GetKruiseOperationsManager().ExecuteOperation(c.Request.Context(), cs, req)
 	if !result.Success {
-		return errors.NewInternalError(fmt.Errorf(result.ErrorDetail))
+		return errors.NewInternalError(fmt.Errorf("%s", result.ErrorDetail))
 	}
 
 	return nil
@@ -121,4 +121,5 @@ func (h *AdvancedStatefulSetHandler) Restart(c *gin.Context, namespace, name str
 func (h *AdvancedStatefulSetHandler) registerCustomRoutes(group *gin.RouterGroup) {
 	group.POST("/:namespace/:name/scale", h.ScaleAdvancedStatefulSet)
 	group.POST("/:namespace/:name/restart", h.RestartAdvancedStatefulSet)
+	registerKruiseRevisionRoutes(group, "advancedstatefulsets")
 }
@@ -112,7 +112,7 @@ func (h *CloneSetHandler) Restart(c *gin.Context, namespace, name string) error
 
 	result := GetKruiseOperationsManager().ExecuteOperation(c.Request.Context(), cs, req)
 	if !result.Success {
-		return errors.NewInternalError(fmt.Errorf(result.ErrorDetail))
+		return errors.NewInternalError(fmt.Errorf("%s", result.ErrorDetail))
 	}
 
 	return nil
@@ -121,4 +121,5 @@ func (h *CloneSetHandler) Restart(c *gin.Context, namespace, name string) error
 func (h *CloneSetHandler) registerCustomRoutes(group *gin.RouterGroup) {
 	group.POST("/:namespace/:name/scale", h.ScaleCloneSet)
 	group.POST("/:namespace/:name/restart", h.RestartCloneSet)
+	registerKruiseRevisionRoutes(group, "clonesets")
 }
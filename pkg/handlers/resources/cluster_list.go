@@ -0,0 +1,79 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/zxh326/kite/pkg/cluster"
+)
+
+// ClusterList is a list request of a typed resource that isn't bound to the
+// cluster of the request, so the same list can run against several clusters
+type ClusterList struct {
+	handler watchableHandler
+	filter  *watchFilter
+	limit   int
+}
+
+// NewClusterList parses the namespace, labelSelector, fieldSelector and limit
+// parameters like List does
+func NewClusterList(c *gin.Context, resource string) (*ClusterList, error) {
+	handler, ok := handlers[resource].(watchableHandler)
+	if !ok {
+		return nil, fmt.Errorf("resource %s does not support listing across clusters", resource)
+	}
+	filter, err := newWatchFilter(c, handlers[resource].IsClusterScoped())
+	if err != nil {
+		return nil, err
+	}
+	list := &ClusterList{handler: handler, filter: filter}
+	if c.Query("limit") != "" {
+		if list.limit, err = strconv.Atoi(c.Query("limit")); err != nil || list.limit < 0 {
+			return nil, fmt.Errorf("invalid limit parameter")
+		}
+	}
+	return list, nil
+}
+
+// Namespace is the namespace the list is restricted to, empty for all
+func (l *ClusterList) Namespace() string {
+	return l.filter.namespace
+}
+
+// Run lists the matching objects of one cluster, newest first. Field
+// selectors are evaluated locally since the cache only supports indexed fields.
+func (l *ClusterList) Run(ctx context.Context, cs *cluster.ClientSet) ([]client.Object, error) {
+	objectList := l.handler.newList()
+	opts := &client.ListOptions{Namespace: l.filter.namespace, LabelSelector: l.filter.labels}
+	if err := cs.K8sClient.List(ctx, objectList, opts); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(objectList)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]client.Object, 0, len(items))
+	for _, item := range items {
+		if obj, ok := item.(client.Object); ok && l.filter.matches(obj) {
+			objects = append(objects, obj)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		t1, t2 := objects[i].GetCreationTimestamp(), objects[j].GetCreationTimestamp()
+		if t1.Equal(&t2) {
+			return objects[i].GetName() < objects[j].GetName()
+		}
+		return t1.After(t2.Time)
+	})
+	if l.limit > 0 && len(objects) > l.limit {
+		objects = objects[:l.limit]
+	}
+	return objects, nil
+}
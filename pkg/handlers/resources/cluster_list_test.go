@@ -0,0 +1,64 @@
+package resources
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+func TestClusterListRun(t *testing.T) {
+	handlers = map[string]resourceHandler{
+		"pods": NewGenericResourceHandler[*corev1.Pod, *corev1.PodList]("pods", false, true),
+	}
+	defer func() { handlers = map[string]resourceHandler{} }()
+
+	now := time.Now()
+	pod := func(name, namespace, node string, age time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				Labels:            map[string]string{"app": "web"},
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: corev1.PodSpec{NodeName: node},
+		}
+	}
+	cs := &cluster.ClientSet{
+		K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().WithObjects(
+			pod("old", "default", "node-a", time.Hour),
+			pod("new", "default", "node-a", time.Minute),
+			pod("other-node", "default", "node-b", time.Minute),
+			pod("other-namespace", "kube-system", "node-a", time.Minute),
+		).Build()},
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/multicluster/pods/default?labelSelector=app%3Dweb&fieldSelector=spec.nodeName%3Dnode-a", nil)
+	c.Params = gin.Params{{Key: "resource", Value: "pods"}, {Key: "namespace", Value: "default"}}
+	list, err := NewClusterList(c, "pods")
+	require.NoError(t, err)
+	assert.Equal(t, "default", list.Namespace())
+
+	objects, err := list.Run(context.Background(), cs)
+	require.NoError(t, err)
+	names := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		names = append(names, obj.GetName())
+	}
+	assert.Equal(t, []string{"new", "old"}, names)
+
+	_, err = NewClusterList(c, "connectors")
+	assert.Error(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -12,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/zxh326/kite/pkg/audit"
 	"github.com/zxh326/kite/pkg/cluster"
 	"github.com/zxh326/kite/pkg/common"
 	"github.com/zxh326/kite/pkg/kube"
@@ -56,6 +58,45 @@ func (h *CRHandler) getGVRFromCRD(crd *apiextensionsv1.CustomResourceDefinition)
 	}
 }
 
+// isServedVersion reports whether the CRD serves the given version
+func isServedVersion(crd *apiextensionsv1.CustomResourceDefinition, version string) bool {
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version {
+			return v.Served
+		}
+	}
+	return false
+}
+
+// invalidCauses returns the causes of an Invalid API error, one per field
+func invalidCauses(err error) ([]string, bool) {
+	if !errors.IsInvalid(err) {
+		return nil, false
+	}
+	var details []string
+	if status, ok := err.(errors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Field != "" {
+				details = append(details, cause.Field+": "+cause.Message)
+			} else {
+				details = append(details, cause.Message)
+			}
+		}
+	}
+	if len(details) == 0 {
+		details = append(details, err.Error())
+	}
+	return details, true
+}
+
+// apiErrorCode returns the HTTP status of an API error, 500 for other errors
+func apiErrorCode(err error) int {
+	if status, ok := err.(errors.APIStatus); ok && status.Status().Code >= http.StatusBadRequest {
+		return int(status.Status().Code)
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *CRHandler) List(c *gin.Context) {
 	crdName := c.Param("crd")
 	if crdName == "" {
@@ -206,6 +247,11 @@ func (h *CRHandler) Create(c *gin.Context) {
 		return
 	}
 
+	// Keep the requested version if the CRD serves it, otherwise use the default one
+	if gv, err := schema.ParseGroupVersion(cr.GetAPIVersion()); err == nil && gv.Group == gvr.Group && isServedVersion(crd, gv.Version) {
+		gvr.Version = gv.Version
+	}
+
 	// Set correct GVK
 	cr.SetGroupVersionKind(schema.GroupVersionKind{
 		Group:   gvr.Group,
@@ -216,21 +262,37 @@ func (h *CRHandler) Create(c *gin.Context) {
 	// Set namespace for namespaced resources
 	if crd.Spec.Scope == apiextensionsv1.NamespaceScoped {
 		namespace := c.Param("namespace")
-		if namespace == "_all" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "This custom resource is namespace-scoped, use /:crd/:namespace endpoint"})
-			return
-		}
 		if namespace == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "namespace is required for namespaced custom resources"})
 			return
 		}
 		cr.SetNamespace(namespace)
+	} else {
+		if namespace := c.Param("namespace"); namespace != "" && namespace != "_all" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This custom resource is cluster-scoped, use /:crd/_all endpoint"})
+			return
+		}
+		cr.SetNamespace("")
+	}
+
+	if cr.GetName() == "" && cr.GetGenerateName() == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metadata.name is required"})
+		return
 	}
 
 	if err := cs.K8sClient.Create(ctx, &cr); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// The API server reports every invalid field at once
+		if details, ok := invalidCauses(err); ok {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Custom resource is invalid: " + strings.Join(details, "; "),
+				"details": details,
+			})
+			return
+		}
+		c.JSON(apiErrorCode(err), gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, nil, &cr)
 
 	c.JSON(http.StatusCreated, cr)
 }
@@ -315,6 +377,7 @@ func (h *CRHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, existingCR, &updatedCR)
 
 	c.JSON(http.StatusOK, updatedCR)
 }
@@ -387,6 +450,7 @@ func (h *CRHandler) Delete(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, cr, nil)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Custom resource deleted successfully"})
 }
@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+func TestCreateCustomResource(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scheme := runtime.NewScheme()
+	require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Kind: "Widget"},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1", Served: true, Storage: true},
+			},
+		},
+	}
+	// Stands in for the API server, which rejects widgets without replicas
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(crd).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			cr := obj.(*unstructured.Unstructured)
+			switch cr.GetName() {
+			case "existing":
+				return errors.NewAlreadyExists(schema.GroupResource{Group: "example.com", Resource: "widgets"}, cr.GetName())
+			case "forbidden":
+				return errors.NewForbidden(schema.GroupResource{Group: "example.com", Resource: "widgets"}, cr.GetName(), nil)
+			}
+			if _, ok, _ := unstructured.NestedInt64(cr.Object, "spec", "replicas"); !ok {
+				return errors.NewInvalid(schema.GroupKind{Group: "example.com", Kind: "Widget"}, cr.GetName(), field.ErrorList{
+					field.Required(field.NewPath("spec", "replicas"), ""),
+					field.Invalid(field.NewPath("spec", "mode"), "yolo", "must be fast or safe"),
+				})
+			}
+			return nil
+		},
+	}).Build()
+	cs := &cluster.ClientSet{K8sClient: &kube.K8sClient{Client: k8sClient}}
+
+	create := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		c.Params = gin.Params{{Key: "crd", Value: "widgets.example.com"}, {Key: "namespace", Value: "default"}}
+		c.Set("cluster", cs)
+		NewCRHandler().Create(c)
+		return w
+	}
+
+	assert.Equal(t, http.StatusCreated, create(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"widget"},"spec":{"replicas":2}}`).Code)
+
+	w := create(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"widget"},"spec":{"mode":"yolo"}}`)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var body struct {
+		Details []string `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, []string{
+		"spec.replicas: Required value",
+		`spec.mode: Invalid value: "yolo": must be fast or safe`,
+	}, body.Details)
+
+	assert.Equal(t, http.StatusConflict, create(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"existing"},"spec":{"replicas":2}}`).Code)
+	assert.Equal(t, http.StatusForbidden, create(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"forbidden"},"spec":{"replicas":2}}`).Code)
+}
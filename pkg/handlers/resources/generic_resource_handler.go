@@ -5,7 +5,6 @@ import (
 	"reflect"
 	"sort"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -15,6 +14,7 @@ import (
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/zxh326/kite/pkg/audit"
 	"github.com/zxh326/kite/pkg/cluster"
 	"github.com/zxh326/kite/pkg/common"
 
@@ -198,6 +198,7 @@ func (h *GenericResourceHandler[T, V]) Create(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, nil, resource)
 
 	c.JSON(http.StatusCreated, resource)
 }
@@ -220,10 +221,17 @@ func (h *GenericResourceHandler[T, V]) Update(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
+	// Keep the current version around for the audit diff
+	var before any
+	existing := reflect.New(h.objectType).Interface().(T)
+	if err := cs.K8sClient.Get(ctx, client.ObjectKeyFromObject(resource), existing); err == nil {
+		before = existing
+	}
 	if err := cs.K8sClient.Update(ctx, resource); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, before, resource)
 
 	c.JSON(http.StatusOK, resource)
 }
@@ -295,16 +303,27 @@ func (h *GenericResourceHandler[T, V]) Delete(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, resource, nil)
 
 	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully"})
 }
 
+// Search matches names, labels, annotations, images, node names and IPs,
+// see searchQuery for the syntax. Cached kinds are searched in the index of
+// the cluster, others are listed.
 func (h *GenericResourceHandler[T, V]) Search(c *gin.Context, q string, limit int64) ([]common.SearchResult, error) {
-	if !h.enableSearch || len(q) < 3 {
+	query := parseSearchQuery(q)
+	if !query.valid() {
 		return nil, nil
 	}
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
 	ctx := c.Request.Context()
+	if idx := searchIndexFor(cs.K8sClient); idx != nil {
+		if kind, ok := idx.kind(ctx, h.newObject()); ok {
+			return kind.search(h.name, query, limit), nil
+		}
+	}
+
 	objectList := reflect.New(h.listType).Interface().(V)
 	if err := cs.K8sClient.List(ctx, objectList); err != nil {
 		klog.Errorf("failed to list %s: %v", h.name, err)
@@ -316,31 +335,16 @@ func (h *GenericResourceHandler[T, V]) Search(c *gin.Context, q string, limit in
 		return nil, err
 	}
 
-	results := make([]common.SearchResult, 0, limit)
-
+	objects := make([]client.Object, 0, len(items))
 	for _, item := range items {
 		obj, ok := item.(client.Object)
 		if !ok {
 			klog.Errorf("item is not a client.Object: %v", item)
 			continue
 		}
-		if !strings.Contains(strings.ToLower(obj.GetName()), strings.ToLower(q)) {
-			continue
-		}
-		result := common.SearchResult{
-			ID:           string(obj.GetUID()),
-			Name:         obj.GetName(),
-			Namespace:    obj.GetNamespace(),
-			ResourceType: h.name,
-			CreatedAt:    obj.GetCreationTimestamp().String(),
-		}
-		results = append(results, result)
-		if limit > 0 && int64(len(results)) >= limit {
-			break
-		}
+		objects = append(objects, obj)
 	}
-
-	return results, nil
+	return searchObjects(h.name, objects, q, limit), nil
 }
 
 func (h *GenericResourceHandler[T, V]) registerCustomRoutes(group *gin.RouterGroup) {}
@@ -885,6 +885,15 @@ type Restartable interface {
 
 var handlers = map[string]resourceHandler{}
 
+// withResource sets the resource type for handlers shared by several
+// resources, which read it from the context
+func withResource(resource string, handle gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("resource", resource)
+		handle(c)
+	}
+}
+
 func RegisterRoutes(group *gin.RouterGroup) {
 	// Note: All handlers now get the k8s client from gin context instead of being passed it during initialization
 	// We pass nil as the k8sClient parameter since handlers will get it from context
@@ -975,6 +984,13 @@ func RegisterRoutes(group *gin.RouterGroup) {
 		}
 	}
 
+	// Watch endpoints stream changes of a resource as server-sent events
+	watchGroup := group.Group("/watch")
+	{
+		watchGroup.GET("/:resource", WatchResource)
+		watchGroup.GET("/:resource/:namespace", WatchResource)
+	}
+
 	// OpenKruise detection endpoint
 	group.GET("/openkruise/status", GetOpenKruiseStatus)
 
@@ -1017,16 +1033,28 @@ func RegisterRoutes(group *gin.RouterGroup) {
 		}
 	}
 
+	// Revision history, rollback and rollout status of workloads
+	rolloutHandler := &RolloutHandler{}
+	for _, resourceType := range historyResources {
+		g := group.Group("/" + resourceType)
+		g.GET("/:namespace/:name/history", withResource(resourceType, rolloutHandler.ListHistory))
+		g.GET("/:namespace/:name/history/diff", withResource(resourceType, rolloutHandler.DiffRevisions))
+		g.POST("/:namespace/:name/rollback", withResource(resourceType, rolloutHandler.Rollback))
+		g.GET("/:namespace/:name/rollout-status", withResource(resourceType, rolloutHandler.GetRolloutStatus))
+	}
+
 	crHandler := NewCRHandler()
 	otherGroup := group.Group("/:crd")
 	{
 		otherGroup.GET("", crHandler.List)
 		otherGroup.GET("/_all", crHandler.List)
+		otherGroup.POST("/_all", crHandler.Create)
 		otherGroup.GET("/_all/:name", crHandler.Get)
 		otherGroup.PUT("/_all/:name", crHandler.Update)
 		otherGroup.DELETE("/_all/:name", crHandler.Delete)
 
 		otherGroup.GET("/:namespace", crHandler.List)
+		otherGroup.POST("/:namespace", crHandler.Create)
 		otherGroup.GET("/:namespace/:name", crHandler.Get)
 		otherGroup.PUT("/:namespace/:name", crHandler.Update)
 		otherGroup.DELETE("/:namespace/:name", crHandler.Delete)
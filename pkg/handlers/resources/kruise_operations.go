@@ -2,11 +2,16 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
 
 	"github.com/zxh326/kite/pkg/cluster"
 
@@ -20,8 +25,12 @@ import (
 type KruiseOperationType string
 
 const (
-	KruiseScale   KruiseOperationType = "scale"
-	KruiseRestart KruiseOperationType = "restart"
+	KruiseScale     KruiseOperationType = "scale"
+	KruiseRestart   KruiseOperationType = "restart"
+	KruisePause     KruiseOperationType = "pause"
+	KruiseResume    KruiseOperationType = "resume"
+	KruisePartition KruiseOperationType = "partition"
+	KruiseRevisions KruiseOperationType = "revisions"
 )
 
 // RestartedEnvKey is the environment variable key used for triggering restarts
@@ -54,7 +63,11 @@ type KruiseOperationRequest struct {
 	Operation    KruiseOperationType `json:"operation"`
 	Namespace    string              `json:"namespace"`
 	Name         string              `json:"name"`
-	Replicas     *int32              `json:"replicas,omitempty"` // For scale operations
+	Replicas     *int32              `json:"replicas,omitempty"`  // For scale operations
+	Partition    *int32              `json:"partition,omitempty"` // For partition operations
+	// Release sets the partition so that this many pods run the update
+	// revision, it is used when Partition is not set
+	Release *int32 `json:"release,omitempty"`
 }
 
 // updateContainerEnv updates environment variables for a container
@@ -85,6 +98,39 @@ type KruiseOperationResult struct {
 	ErrorDetail     string `json:"error,omitempty"`
 	RestartedAt     string `json:"restartedAt,omitempty"`     // For restart operations
 	CurrentReplicas *int32 `json:"currentReplicas,omitempty"` // For scale operations
+	// Revisions is the revision status after pause, resume, partition and
+	// revisions operations
+	Revisions *KruiseRevisionStatus `json:"revisions,omitempty"`
+
+	// err is the cause of a failed operation, it selects the response status
+	err error
+}
+
+// unsupportedOperationError is returned for operations a workload type
+// doesn't support
+type unsupportedOperationError struct {
+	message string
+}
+
+func (e *unsupportedOperationError) Error() string {
+	return e.message
+}
+
+func errUnsupported(format string, args ...any) error {
+	return &unsupportedOperationError{message: fmt.Sprintf(format, args...)}
+}
+
+// KruiseRevisionStatus reports how far a workload is rolled out to its
+// update revision, pods below the partition keep the current revision
+type KruiseRevisionStatus struct {
+	Replicas             int32  `json:"replicas"`
+	ReadyReplicas        int32  `json:"readyReplicas"`
+	UpdatedReplicas      int32  `json:"updatedReplicas"`
+	UpdatedReadyReplicas int32  `json:"updatedReadyReplicas"`
+	CurrentRevision      string `json:"currentRevision,omitempty"`
+	UpdateRevision       string `json:"updateRevision,omitempty"`
+	Partition            int32  `json:"partition"`
+	Paused               bool   `json:"paused"`
 }
 
 // KruiseOperationsInterface defines operations for OpenKruise workloads
@@ -93,6 +139,13 @@ type KruiseOperationsInterface interface {
 	Restart(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (string, error) // Returns restartedAt timestamp
 	GetWorkloadType() KruiseWorkloadType
 	ValidateRestart(ctx context.Context, cs *cluster.ClientSet, namespace, name string) error // Validate if restart is possible
+	// Pause pauses or resumes the rollout of the update revision, it returns
+	// the revision status of the updated workload
+	Pause(ctx context.Context, cs *cluster.ClientSet, namespace, name string, paused bool) (*KruiseRevisionStatus, error)
+	// SetPartition keeps partition pods at the current revision, it returns
+	// the revision status of the updated workload
+	SetPartition(ctx context.Context, cs *cluster.ClientSet, namespace, name string, partition int32) (*KruiseRevisionStatus, error)
+	GetRevisionStatus(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (*KruiseRevisionStatus, error)
 }
 
 // CloneSetOperations implements operations for CloneSet
@@ -159,6 +212,74 @@ func (o *CloneSetOperations) Restart(ctx context.Context, cs *cluster.ClientSet,
 	return restartedAt, nil
 }
 
+func (o *CloneSetOperations) Pause(ctx context.Context, cs *cluster.ClientSet, namespace, name string, paused bool) (*KruiseRevisionStatus, error) {
+	var cloneSet kruiseappsv1alpha1.CloneSet
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cloneSet); err != nil {
+			return fmt.Errorf("failed to get CloneSet %s/%s: %w", namespace, name, err)
+		}
+
+		cloneSet.Spec.UpdateStrategy.Paused = paused
+		if err := cs.K8sClient.Update(ctx, &cloneSet); err != nil {
+			return fmt.Errorf("failed to update CloneSet update strategy: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneSetRevisionStatus(&cloneSet)
+}
+
+func (o *CloneSetOperations) SetPartition(ctx context.Context, cs *cluster.ClientSet, namespace, name string, partition int32) (*KruiseRevisionStatus, error) {
+	var cloneSet kruiseappsv1alpha1.CloneSet
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cloneSet); err != nil {
+			return fmt.Errorf("failed to get CloneSet %s/%s: %w", namespace, name, err)
+		}
+
+		cloneSet.Spec.UpdateStrategy.Partition = ptr.To(intstr.FromInt32(partition))
+		if err := cs.K8sClient.Update(ctx, &cloneSet); err != nil {
+			return fmt.Errorf("failed to update CloneSet partition: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneSetRevisionStatus(&cloneSet)
+}
+
+func (o *CloneSetOperations) GetRevisionStatus(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (*KruiseRevisionStatus, error) {
+	var cloneSet kruiseappsv1alpha1.CloneSet
+	if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cloneSet); err != nil {
+		return nil, fmt.Errorf("failed to get CloneSet %s/%s: %w", namespace, name, err)
+	}
+	return cloneSetRevisionStatus(&cloneSet)
+}
+
+func cloneSetRevisionStatus(cloneSet *kruiseappsv1alpha1.CloneSet) (*KruiseRevisionStatus, error) {
+	status := &KruiseRevisionStatus{
+		Replicas:             ptr.Deref(cloneSet.Spec.Replicas, 1),
+		ReadyReplicas:        cloneSet.Status.ReadyReplicas,
+		UpdatedReplicas:      cloneSet.Status.UpdatedReplicas,
+		UpdatedReadyReplicas: cloneSet.Status.UpdatedReadyReplicas,
+		CurrentRevision:      cloneSet.Status.CurrentRevision,
+		UpdateRevision:       cloneSet.Status.UpdateRevision,
+		Paused:               cloneSet.Spec.UpdateStrategy.Paused,
+	}
+	// The partition may be a percentage of the replicas
+	if cloneSet.Spec.UpdateStrategy.Partition != nil {
+		partition, err := intstr.GetScaledValueFromIntOrPercent(cloneSet.Spec.UpdateStrategy.Partition, int(status.Replicas), true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CloneSet partition: %w", err)
+		}
+		status.Partition = int32(partition)
+	}
+
+	return status, nil
+}
+
 // AdvancedStatefulSetOperations implements operations for AdvancedStatefulSet
 type AdvancedStatefulSetOperations struct{}
 
@@ -223,6 +344,77 @@ func (o *AdvancedStatefulSetOperations) Restart(ctx context.Context, cs *cluster
 	return restartedAt, nil
 }
 
+func (o *AdvancedStatefulSetOperations) Pause(ctx context.Context, cs *cluster.ClientSet, namespace, name string, paused bool) (*KruiseRevisionStatus, error) {
+	var statefulSet kruiseappsv1beta1.StatefulSet
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &statefulSet); err != nil {
+			return fmt.Errorf("failed to get AdvancedStatefulSet %s/%s: %w", namespace, name, err)
+		}
+
+		if statefulSet.Spec.UpdateStrategy.RollingUpdate == nil {
+			statefulSet.Spec.UpdateStrategy.RollingUpdate = &kruiseappsv1beta1.RollingUpdateStatefulSetStrategy{}
+		}
+		statefulSet.Spec.UpdateStrategy.RollingUpdate.Paused = paused
+		if err := cs.K8sClient.Update(ctx, &statefulSet); err != nil {
+			return fmt.Errorf("failed to update AdvancedStatefulSet update strategy: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return advancedStatefulSetRevisionStatus(&statefulSet), nil
+}
+
+func (o *AdvancedStatefulSetOperations) SetPartition(ctx context.Context, cs *cluster.ClientSet, namespace, name string, partition int32) (*KruiseRevisionStatus, error) {
+	var statefulSet kruiseappsv1beta1.StatefulSet
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &statefulSet); err != nil {
+			return fmt.Errorf("failed to get AdvancedStatefulSet %s/%s: %w", namespace, name, err)
+		}
+
+		if statefulSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			return errUnsupported("AdvancedStatefulSet with OnDelete update strategy does not support partition")
+		}
+		if statefulSet.Spec.UpdateStrategy.RollingUpdate == nil {
+			statefulSet.Spec.UpdateStrategy.RollingUpdate = &kruiseappsv1beta1.RollingUpdateStatefulSetStrategy{}
+		}
+		statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+		if err := cs.K8sClient.Update(ctx, &statefulSet); err != nil {
+			return fmt.Errorf("failed to update AdvancedStatefulSet partition: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return advancedStatefulSetRevisionStatus(&statefulSet), nil
+}
+
+func (o *AdvancedStatefulSetOperations) GetRevisionStatus(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (*KruiseRevisionStatus, error) {
+	var statefulSet kruiseappsv1beta1.StatefulSet
+	if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &statefulSet); err != nil {
+		return nil, fmt.Errorf("failed to get AdvancedStatefulSet %s/%s: %w", namespace, name, err)
+	}
+	return advancedStatefulSetRevisionStatus(&statefulSet), nil
+}
+
+func advancedStatefulSetRevisionStatus(statefulSet *kruiseappsv1beta1.StatefulSet) *KruiseRevisionStatus {
+	status := &KruiseRevisionStatus{
+		Replicas:             ptr.Deref(statefulSet.Spec.Replicas, 1),
+		ReadyReplicas:        statefulSet.Status.ReadyReplicas,
+		UpdatedReplicas:      statefulSet.Status.UpdatedReplicas,
+		UpdatedReadyReplicas: statefulSet.Status.UpdatedReadyReplicas,
+		CurrentRevision:      statefulSet.Status.CurrentRevision,
+		UpdateRevision:       statefulSet.Status.UpdateRevision,
+	}
+	if rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil {
+		status.Partition = ptr.Deref(rollingUpdate.Partition, 0)
+		status.Paused = rollingUpdate.Paused
+	}
+	return status
+}
+
 // UnitedDeploymentOperations implements operations for UnitedDeployment
 type UnitedDeploymentOperations struct{}
 
@@ -333,6 +525,18 @@ func (o *UnitedDeploymentOperations) Restart(ctx context.Context, cs *cluster.Cl
 	return restartedAt, nil
 }
 
+func (o *UnitedDeploymentOperations) Pause(ctx context.Context, cs *cluster.ClientSet, namespace, name string, paused bool) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("UnitedDeployment does not support pausing the update")
+}
+
+func (o *UnitedDeploymentOperations) SetPartition(ctx context.Context, cs *cluster.ClientSet, namespace, name string, partition int32) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("UnitedDeployment does not support update partition")
+}
+
+func (o *UnitedDeploymentOperations) GetRevisionStatus(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("UnitedDeployment does not support revision status")
+}
+
 // AdvancedDaemonSetOperations implements operations for AdvancedDaemonSet
 type AdvancedDaemonSetOperations struct{}
 
@@ -385,6 +589,18 @@ func (o *AdvancedDaemonSetOperations) Restart(ctx context.Context, cs *cluster.C
 	return restartedAt, nil
 }
 
+func (o *AdvancedDaemonSetOperations) Pause(ctx context.Context, cs *cluster.ClientSet, namespace, name string, paused bool) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("AdvancedDaemonSet does not support pausing the update")
+}
+
+func (o *AdvancedDaemonSetOperations) SetPartition(ctx context.Context, cs *cluster.ClientSet, namespace, name string, partition int32) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("AdvancedDaemonSet does not support update partition")
+}
+
+func (o *AdvancedDaemonSetOperations) GetRevisionStatus(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("AdvancedDaemonSet does not support revision status")
+}
+
 // Native Kubernetes Resource Operations
 
 // StatefulSetOperations implements operations for native StatefulSet
@@ -449,6 +665,18 @@ func (o *StatefulSetOperations) Restart(ctx context.Context, cs *cluster.ClientS
 	return restartedAt, nil
 }
 
+func (o *StatefulSetOperations) Pause(ctx context.Context, cs *cluster.ClientSet, namespace, name string, paused bool) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("StatefulSet does not support pausing the update")
+}
+
+func (o *StatefulSetOperations) SetPartition(ctx context.Context, cs *cluster.ClientSet, namespace, name string, partition int32) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("StatefulSet does not support update partition")
+}
+
+func (o *StatefulSetOperations) GetRevisionStatus(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("StatefulSet does not support revision status")
+}
+
 // DaemonSetOperations implements operations for native DaemonSet
 type DaemonSetOperations struct{}
 
@@ -499,6 +727,18 @@ func (o *DaemonSetOperations) Restart(ctx context.Context, cs *cluster.ClientSet
 	return restartedAt, nil
 }
 
+func (o *DaemonSetOperations) Pause(ctx context.Context, cs *cluster.ClientSet, namespace, name string, paused bool) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("DaemonSet does not support pausing the update")
+}
+
+func (o *DaemonSetOperations) SetPartition(ctx context.Context, cs *cluster.ClientSet, namespace, name string, partition int32) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("DaemonSet does not support update partition")
+}
+
+func (o *DaemonSetOperations) GetRevisionStatus(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("DaemonSet does not support revision status")
+}
+
 // DeploymentOperations implements operations for native Deployment
 type DeploymentOperations struct{}
 
@@ -561,6 +801,18 @@ func (o *DeploymentOperations) Restart(ctx context.Context, cs *cluster.ClientSe
 	return restartedAt, nil
 }
 
+func (o *DeploymentOperations) Pause(ctx context.Context, cs *cluster.ClientSet, namespace, name string, paused bool) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("Deployment does not support pausing the update")
+}
+
+func (o *DeploymentOperations) SetPartition(ctx context.Context, cs *cluster.ClientSet, namespace, name string, partition int32) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("Deployment does not support update partition")
+}
+
+func (o *DeploymentOperations) GetRevisionStatus(ctx context.Context, cs *cluster.ClientSet, namespace, name string) (*KruiseRevisionStatus, error) {
+	return nil, errUnsupported("Deployment does not support revision status")
+}
+
 // KruiseOperationsManager manages operations for different Kruise workload types
 type KruiseOperationsManager struct {
 	operations map[KruiseWorkloadType]KruiseOperationsInterface
@@ -590,7 +842,7 @@ func NewKruiseOperationsManager() *KruiseOperationsManager {
 func (m *KruiseOperationsManager) GetOperations(workloadType KruiseWorkloadType) (KruiseOperationsInterface, error) {
 	ops, exists := m.operations[workloadType]
 	if !exists {
-		return nil, fmt.Errorf("operations for workload type %s not supported", workloadType)
+		return nil, errUnsupported("operations for workload type %s not supported", workloadType)
 	}
 	return ops, nil
 }
@@ -603,6 +855,7 @@ func (m *KruiseOperationsManager) ExecuteOperation(ctx context.Context, cs *clus
 			Success:     false,
 			Message:     "Operation failed",
 			ErrorDetail: err.Error(),
+			err:         err,
 		}
 	}
 
@@ -643,6 +896,50 @@ func (m *KruiseOperationsManager) ExecuteOperation(ctx context.Context, cs *clus
 			RestartedAt: restartedAt,
 		}
 
+	case KruisePause, KruiseResume:
+		paused := req.Operation == KruisePause
+		message, action := "Resume operation failed", "resumed"
+		if paused {
+			message, action = "Pause operation failed", "paused"
+		}
+		status, err := ops.Pause(ctx, cs, req.Namespace, req.Name, paused)
+		if err != nil {
+			return &KruiseOperationResult{
+				Success:     false,
+				Message:     message,
+				ErrorDetail: err.Error(),
+				err:         err,
+			}
+		}
+		return &KruiseOperationResult{
+			Success:   true,
+			Message:   fmt.Sprintf("%s %s/%s %s", req.WorkloadType, req.Namespace, req.Name, action),
+			Revisions: status,
+		}
+
+	case KruisePartition:
+		var status *KruiseRevisionStatus
+		partition, err := m.resolvePartition(ctx, cs, ops, req)
+		if err == nil {
+			status, err = ops.SetPartition(ctx, cs, req.Namespace, req.Name, partition)
+		}
+		if err != nil {
+			return &KruiseOperationResult{
+				Success:     false,
+				Message:     "Partition operation failed",
+				ErrorDetail: err.Error(),
+				err:         err,
+			}
+		}
+		return &KruiseOperationResult{
+			Success:   true,
+			Message:   fmt.Sprintf("%s %s/%s partition set to %d", req.WorkloadType, req.Namespace, req.Name, partition),
+			Revisions: status,
+		}
+
+	case KruiseRevisions:
+		return m.revisionResult(ctx, cs, ops, req, fmt.Sprintf("%s %s/%s revision status", req.WorkloadType, req.Namespace, req.Name))
+
 	default:
 		return &KruiseOperationResult{
 			Success:     false,
@@ -652,6 +949,47 @@ func (m *KruiseOperationsManager) ExecuteOperation(ctx context.Context, cs *clus
 	}
 }
 
+// resolvePartition returns the partition of a partition request, a release
+// of N pods keeps all but N replicas at the current revision
+func (m *KruiseOperationsManager) resolvePartition(ctx context.Context, cs *cluster.ClientSet, ops KruiseOperationsInterface, req *KruiseOperationRequest) (int32, error) {
+	switch {
+	case req.Partition != nil:
+		if *req.Partition < 0 {
+			return 0, fmt.Errorf("partition must not be negative")
+		}
+		return *req.Partition, nil
+	case req.Release != nil:
+		if *req.Release < 0 {
+			return 0, fmt.Errorf("release must not be negative")
+		}
+		status, err := ops.GetRevisionStatus(ctx, cs, req.Namespace, req.Name)
+		if err != nil {
+			return 0, err
+		}
+		return max(status.Replicas-*req.Release, 0), nil
+	default:
+		return 0, fmt.Errorf("partition or release parameter is required for partition operation")
+	}
+}
+
+// revisionResult reports the revision status of the workload
+func (m *KruiseOperationsManager) revisionResult(ctx context.Context, cs *cluster.ClientSet, ops KruiseOperationsInterface, req *KruiseOperationRequest, message string) *KruiseOperationResult {
+	status, err := ops.GetRevisionStatus(ctx, cs, req.Namespace, req.Name)
+	if err != nil {
+		return &KruiseOperationResult{
+			Success:     false,
+			Message:     "Failed to get revision status",
+			ErrorDetail: err.Error(),
+			err:         err,
+		}
+	}
+	return &KruiseOperationResult{
+		Success:   true,
+		Message:   message,
+		Revisions: status,
+	}
+}
+
 // ParseWorkloadTypeFromResource converts resource name to workload type
 func ParseWorkloadTypeFromResource(resource string) (KruiseWorkloadType, error) {
 	switch resource {
@@ -787,3 +1125,93 @@ func (h *KruiseOperationHandler) RestartKruiseWorkload(c *gin.Context) {
 		})
 	}
 }
+
+// registerKruiseRevisionRoutes registers the canary release routes of a
+// Kruise workload, which go through its update strategy
+func registerKruiseRevisionRoutes(group *gin.RouterGroup, resource string) {
+	h := &KruiseOperationHandler{}
+	group.POST("/:namespace/:name/pause", withResource(resource, h.PauseKruiseWorkload))
+	group.POST("/:namespace/:name/resume", withResource(resource, h.ResumeKruiseWorkload))
+	group.POST("/:namespace/:name/partition", withResource(resource, h.SetKruisePartition))
+	group.GET("/:namespace/:name/revisions", withResource(resource, h.GetKruiseRevisions))
+}
+
+// PauseKruiseWorkload pauses the rollout of the update revision
+func (h *KruiseOperationHandler) PauseKruiseWorkload(c *gin.Context) {
+	h.executeRevisionOperation(c, &KruiseOperationRequest{Operation: KruisePause})
+}
+
+// ResumeKruiseWorkload resumes a paused rollout
+func (h *KruiseOperationHandler) ResumeKruiseWorkload(c *gin.Context) {
+	h.executeRevisionOperation(c, &KruiseOperationRequest{Operation: KruiseResume})
+}
+
+// SetKruisePartition sets the update partition of a workload, either
+// directly or as the number of pods to release to the update revision
+func (h *KruiseOperationHandler) SetKruisePartition(c *gin.Context) {
+	var partitionRequest struct {
+		Partition *int32 `json:"partition" binding:"omitempty,min=0"`
+		Release   *int32 `json:"release" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindJSON(&partitionRequest); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if (partitionRequest.Partition == nil) == (partitionRequest.Release == nil) {
+		c.JSON(400, gin.H{"error": "exactly one of partition or release is required"})
+		return
+	}
+
+	h.executeRevisionOperation(c, &KruiseOperationRequest{
+		Operation: KruisePartition,
+		Partition: partitionRequest.Partition,
+		Release:   partitionRequest.Release,
+	})
+}
+
+// GetKruiseRevisions reports the updated and ready replica counts of the
+// current and update revisions
+func (h *KruiseOperationHandler) GetKruiseRevisions(c *gin.Context) {
+	h.executeRevisionOperation(c, &KruiseOperationRequest{Operation: KruiseRevisions})
+}
+
+func (h *KruiseOperationHandler) executeRevisionOperation(c *gin.Context, req *KruiseOperationRequest) {
+	resource := c.Param("resource")
+	if resource == "" {
+		if resourceFromCtx, exists := c.Get("resource"); exists {
+			resource = resourceFromCtx.(string)
+		}
+	}
+
+	workloadType, err := ParseWorkloadTypeFromResource(resource)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	req.WorkloadType = workloadType
+	req.Namespace = c.Param("namespace")
+	req.Name = c.Param("name")
+
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	result := GetKruiseOperationsManager().ExecuteOperation(c.Request.Context(), cs, req)
+
+	if result.Success {
+		c.JSON(200, gin.H{
+			"message":   result.Message,
+			"revisions": result.Revisions,
+		})
+		return
+	}
+
+	status := 500
+	var unsupported *unsupportedOperationError
+	if apierrors.IsNotFound(result.err) {
+		status = 404
+	} else if errors.As(result.err, &unsupported) {
+		status = 400
+	}
+	c.JSON(status, gin.H{
+		"error":  result.Message,
+		"detail": result.ErrorDetail,
+	})
+}
@@ -0,0 +1,139 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+func TestKruiseCanaryOperations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kruiseappsv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kruiseappsv1beta1.AddToScheme(scheme))
+	cloneSet := &kruiseappsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Replicas:       ptr.To[int32](10),
+			UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{Partition: ptr.To(intstr.FromString("80%"))},
+		},
+		Status: kruiseappsv1alpha1.CloneSetStatus{
+			ReadyReplicas: 10, UpdatedReplicas: 2, UpdatedReadyReplicas: 1,
+			CurrentRevision: "web-1", UpdateRevision: "web-2",
+		},
+	}
+	statefulSet := &kruiseappsv1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       kruiseappsv1beta1.StatefulSetSpec{Replicas: ptr.To[int32](3)},
+	}
+	cs := &cluster.ClientSet{K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(cloneSet, statefulSet).Build()}}
+	ctx := context.Background()
+	manager := NewKruiseOperationsManager()
+
+	result := manager.ExecuteOperation(ctx, cs, &KruiseOperationRequest{WorkloadType: CloneSetType, Operation: KruiseRevisions, Namespace: "default", Name: "web"})
+	require.True(t, result.Success, result.ErrorDetail)
+	assert.Equal(t, &KruiseRevisionStatus{
+		Replicas: 10, ReadyReplicas: 10, UpdatedReplicas: 2, UpdatedReadyReplicas: 1,
+		CurrentRevision: "web-1", UpdateRevision: "web-2", Partition: 8,
+	}, result.Revisions)
+
+	result = manager.ExecuteOperation(ctx, cs, &KruiseOperationRequest{WorkloadType: CloneSetType, Operation: KruisePause, Namespace: "default", Name: "web"})
+	require.True(t, result.Success, result.ErrorDetail)
+	assert.True(t, result.Revisions.Paused)
+
+	result = manager.ExecuteOperation(ctx, cs, &KruiseOperationRequest{WorkloadType: CloneSetType, Operation: KruisePartition, Namespace: "default", Name: "web", Release: ptr.To[int32](5)})
+	require.True(t, result.Success, result.ErrorDetail)
+	assert.Equal(t, int32(5), result.Revisions.Partition)
+	var gotCloneSet kruiseappsv1alpha1.CloneSet
+	require.NoError(t, cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "web"}, &gotCloneSet))
+	assert.Equal(t, ptr.To(intstr.FromInt32(5)), gotCloneSet.Spec.UpdateStrategy.Partition)
+	assert.True(t, gotCloneSet.Spec.UpdateStrategy.Paused)
+
+	result = manager.ExecuteOperation(ctx, cs, &KruiseOperationRequest{WorkloadType: AdvancedStatefulSetType, Operation: KruisePartition, Namespace: "default", Name: "db", Release: ptr.To[int32](5)})
+	require.True(t, result.Success, result.ErrorDetail)
+	assert.Equal(t, int32(0), result.Revisions.Partition)
+
+	result = manager.ExecuteOperation(ctx, cs, &KruiseOperationRequest{WorkloadType: AdvancedStatefulSetType, Operation: KruisePause, Namespace: "default", Name: "db"})
+	require.True(t, result.Success, result.ErrorDetail)
+	result = manager.ExecuteOperation(ctx, cs, &KruiseOperationRequest{WorkloadType: AdvancedStatefulSetType, Operation: KruiseResume, Namespace: "default", Name: "db"})
+	require.True(t, result.Success, result.ErrorDetail)
+	assert.False(t, result.Revisions.Paused)
+	var gotStatefulSet kruiseappsv1beta1.StatefulSet
+	require.NoError(t, cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "db"}, &gotStatefulSet))
+	assert.Equal(t, ptr.To[int32](0), gotStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+
+	result = manager.ExecuteOperation(ctx, cs, &KruiseOperationRequest{WorkloadType: CloneSetType, Operation: KruisePartition, Namespace: "default", Name: "web"})
+	assert.False(t, result.Success)
+	result = manager.ExecuteOperation(ctx, cs, &KruiseOperationRequest{WorkloadType: DaemonSetType, Operation: KruisePause, Namespace: "default", Name: "web"})
+	assert.False(t, result.Success)
+}
+
+func TestKruiseRevisionOperationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scheme := runtime.NewScheme()
+	require.NoError(t, kruiseappsv1alpha1.AddToScheme(scheme))
+	cloneSet := &kruiseappsv1alpha1.CloneSet{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	// The first update conflicts with a concurrent writer
+	conflicts := 1
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cloneSet).WithInterceptorFuncs(interceptor.Funcs{
+		// The cache doesn't see the update yet
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := c.Get(ctx, key, obj, opts...); err != nil {
+				return err
+			}
+			if cloneSet, ok := obj.(*kruiseappsv1alpha1.CloneSet); ok {
+				cloneSet.Spec.UpdateStrategy.Paused = false
+			}
+			return nil
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if conflicts > 0 {
+				conflicts--
+				return apierrors.NewConflict(kruiseappsv1alpha1.Resource("clonesets"), obj.GetName(), nil)
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}).Build()
+	cs := &cluster.ClientSet{K8sClient: &kube.K8sClient{Client: k8sClient}}
+
+	pause := func(resource, name string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		c.Params = gin.Params{{Key: "namespace", Value: "default"}, {Key: "name", Value: name}}
+		c.Set("cluster", cs)
+		withResource(resource, (&KruiseOperationHandler{}).PauseKruiseWorkload)(c)
+		return w
+	}
+	w := pause("clonesets", "web")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Zero(t, conflicts)
+	var body struct {
+		Revisions KruiseRevisionStatus `json:"revisions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.True(t, body.Revisions.Paused, "the status is read from the updated object")
+	assert.Equal(t, http.StatusNotFound, pause("clonesets", "missing").Code)
+	assert.Equal(t, http.StatusBadRequest, pause("deployments", "web").Code)
+}
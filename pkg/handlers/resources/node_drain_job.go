@@ -0,0 +1,337 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+	"github.com/zxh326/kite/pkg/utils"
+)
+
+// DrainJobStatus is the lifecycle state of a drain job
+type DrainJobStatus string
+
+const (
+	DrainJobRunning   DrainJobStatus = "running"
+	DrainJobSucceeded DrainJobStatus = "succeeded"
+	DrainJobFailed    DrainJobStatus = "failed"
+	DrainJobCancelled DrainJobStatus = "cancelled"
+)
+
+const (
+	// defaultDrainJobTimeout bounds how long a drain job may wait on evictions
+	defaultDrainJobTimeout = 30 * time.Minute
+	// drainJobRetention is how long finished jobs stay queryable
+	drainJobRetention = time.Hour
+)
+
+// DrainJob tracks a drain running in the background
+type DrainJob struct {
+	ID         string            `json:"id"`
+	Cluster    string            `json:"cluster"`
+	Node       string            `json:"node"`
+	Options    kube.DrainOptions `json:"options"`
+	Status     DrainJobStatus    `json:"status"`
+	Error      string            `json:"error,omitempty"`
+	Events     []kube.DrainEvent `json:"events"`
+	Result     *kube.DrainResult `json:"result,omitempty"`
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+
+	mu        sync.RWMutex
+	cancel    context.CancelFunc
+	uncordon  bool
+	changed   chan struct{} // closed and replaced whenever the job changes
+	cancelled bool
+	// drained is set as soon as the drainer returns, the job can no longer
+	// be cancelled from then on even though it is not finished yet
+	drained bool
+}
+
+func (j *DrainJob) addEvent(event kube.DrainEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Events = append(j.Events, event)
+	j.notifyLocked()
+}
+
+func (j *DrainJob) notifyLocked() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+func (j *DrainJob) finish(result *kube.DrainResult, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	j.FinishedAt = &now
+	j.Result = result
+	switch {
+	case j.cancelled:
+		j.Status = DrainJobCancelled
+	case err != nil:
+		j.Status = DrainJobFailed
+		j.Error = err.Error()
+	case !result.Success:
+		j.Status = DrainJobFailed
+		j.Error = "some pods could not be evicted"
+	default:
+		j.Status = DrainJobSucceeded
+	}
+	j.notifyLocked()
+}
+
+func (j *DrainJob) isFinished() bool {
+	return j.Status != DrainJobRunning
+}
+
+// snapshot returns events from index `from`, whether the job is done and a channel to wait on for changes
+func (j *DrainJob) snapshot(from int) ([]kube.DrainEvent, bool, <-chan struct{}) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	var events []kube.DrainEvent
+	if from < len(j.Events) {
+		events = append(events, j.Events[from:]...)
+	}
+	return events, j.isFinished(), j.changed
+}
+
+func (j *DrainJob) MarshalJSON() ([]byte, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	type alias DrainJob
+	return json.Marshal((*alias)(j))
+}
+
+// DrainJobManager keeps track of drain jobs across clusters
+type DrainJobManager struct {
+	mu   sync.RWMutex
+	jobs map[string]*DrainJob
+}
+
+func NewDrainJobManager() *DrainJobManager {
+	return &DrainJobManager{
+		jobs: make(map[string]*DrainJob),
+	}
+}
+
+// Start launches a drain job in the background. Only one drain may run per node.
+func (m *DrainJobManager) Start(cs *cluster.ClientSet, nodeName string, options kube.DrainOptions) (*DrainJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+
+	for _, job := range m.jobs {
+		job.mu.RLock()
+		running := job.Cluster == cs.Name && job.Node == nodeName && !job.isFinished()
+		job.mu.RUnlock()
+		if running {
+			return job, fmt.Errorf("node %s already has a running drain job %s", nodeName, job.ID)
+		}
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = defaultDrainJobTimeout
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &DrainJob{
+		ID:        utils.RandomString(12),
+		Cluster:   cs.Name,
+		Node:      nodeName,
+		Options:   options,
+		Status:    DrainJobRunning,
+		Events:    []kube.DrainEvent{},
+		StartedAt: time.Now(),
+		cancel:    cancel,
+		changed:   make(chan struct{}),
+	}
+	m.jobs[job.ID] = job
+
+	go func() {
+		defer cancel()
+		drainer := kube.NewDrainer(cs.K8sClient, options).OnEvent(job.addEvent)
+		result, err := drainer.Drain(ctx, nodeName)
+
+		job.mu.Lock()
+		job.drained = true
+		uncordon := job.cancelled && job.uncordon
+		job.mu.Unlock()
+		if uncordon {
+			// The job context is cancelled, use a fresh one to restore the node
+			uncordonCtx, uncordonCancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := markNodeSchedulable(uncordonCtx, cs.K8sClient, nodeName, true); err != nil {
+				klog.Errorf("Failed to uncordon node %s after cancelling drain job %s: %v", nodeName, job.ID, err)
+			}
+			uncordonCancel()
+		}
+		job.finish(result, err)
+		klog.Infof("Drain job %s for node %s finished", job.ID, nodeName)
+	}()
+
+	return job, nil
+}
+
+// Get returns a job by ID, restricted to the given cluster
+func (m *DrainJobManager) Get(clusterName, id string) (*DrainJob, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[id]
+	if !ok || job.Cluster != clusterName {
+		return nil, false
+	}
+	return job, true
+}
+
+// List returns the jobs of a node, newest first
+func (m *DrainJobManager) List(clusterName, nodeName string) []*DrainJob {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	jobs := make([]*DrainJob, 0)
+	for _, job := range m.jobs {
+		if job.Cluster == clusterName && job.Node == nodeName {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.After(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+// Cancel stops further evictions of a running job and optionally uncordons the node
+func (m *DrainJobManager) Cancel(job *DrainJob, uncordon bool) error {
+	job.mu.Lock()
+	if job.isFinished() {
+		job.mu.Unlock()
+		return fmt.Errorf("drain job %s is already %s", job.ID, job.Status)
+	}
+	if job.drained {
+		job.mu.Unlock()
+		return fmt.Errorf("drain job %s has already finished draining", job.ID)
+	}
+	job.cancelled = true
+	job.uncordon = uncordon
+	job.mu.Unlock()
+	job.cancel()
+	return nil
+}
+
+// pruneLocked removes finished jobs older than the retention period
+func (m *DrainJobManager) pruneLocked() {
+	for id, job := range m.jobs {
+		job.mu.RLock()
+		expired := job.FinishedAt != nil && time.Since(*job.FinishedAt) > drainJobRetention
+		job.mu.RUnlock()
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// Global drain job manager instance
+var globalDrainJobManager = NewDrainJobManager()
+
+// getDrainJob resolves the job from the request path and checks it belongs to the node
+func getDrainJob(c *gin.Context) (*DrainJob, bool) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	job, ok := globalDrainJobManager.Get(cs.Name, c.Param("jobId"))
+	if !ok || job.Node != c.Param("name") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Drain job not found"})
+		return nil, false
+	}
+	return job, true
+}
+
+// ListDrainJobs lists the drain jobs of a node
+func (h *NodeHandler) ListDrainJobs(c *gin.Context) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	c.JSON(http.StatusOK, gin.H{
+		"items": globalDrainJobManager.List(cs.Name, c.Param("name")),
+	})
+}
+
+// GetDrainJob returns the current status of a drain job
+func (h *NodeHandler) GetDrainJob(c *gin.Context) {
+	job, ok := getDrainJob(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelDrainJob cancels a running drain job, uncordon=true makes the node schedulable again
+func (h *NodeHandler) CancelDrainJob(c *gin.Context) {
+	job, ok := getDrainJob(c)
+	if !ok {
+		return
+	}
+	if err := globalDrainJobManager.Cancel(job, c.Query("uncordon") == "true"); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": fmt.Sprintf("Drain job %s cancellation requested", job.ID),
+	})
+}
+
+// StreamDrainJob streams drain job events using Server-Sent Events
+func (h *NodeHandler) StreamDrainJob(c *gin.Context) {
+	job, ok := getDrainJob(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	if _, err := c.Writer.WriteString("event: connected\ndata: {\"status\":\"connected\"}\n\n"); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	sent := 0
+	for {
+		events, finished, changed := job.snapshot(sent)
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+		sent += len(events)
+		c.Writer.Flush()
+
+		if finished {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+	}
+
+	data, err := json.Marshal(job)
+	if err == nil {
+		_, _ = fmt.Fprintf(c.Writer, "event: done\ndata: %s\n\n", data)
+	}
+	if _, err := c.Writer.WriteString("event: close\ndata: {\"status\":\"closed\"}\n\n"); err != nil {
+		return
+	}
+	c.Writer.Flush()
+}
@@ -0,0 +1,134 @@
+package resources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+// newBlockingDrainClientSet returns a cluster whose pod listing blocks until
+// the drain is cancelled, so drain jobs stay running
+func newBlockingDrainClientSet() *cluster.ClientSet {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(node).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}).Build()
+	return &cluster.ClientSet{Name: "test", K8sClient: &kube.K8sClient{Client: k8sClient}}
+}
+
+func waitForDrainJob(t *testing.T, job *DrainJob) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		_, finished, _ := job.snapshot(0)
+		return finished
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDrainJobManagerCancel(t *testing.T) {
+	for _, uncordon := range []bool{false, true} {
+		cs := newBlockingDrainClientSet()
+		manager := NewDrainJobManager()
+
+		job, err := manager.Start(cs, "node-1", kube.DrainOptions{})
+		require.NoError(t, err)
+		_, err = manager.Start(cs, "node-1", kube.DrainOptions{})
+		assert.Error(t, err, "a node only runs one drain job")
+
+		require.NoError(t, manager.Cancel(job, uncordon))
+		waitForDrainJob(t, job)
+		assert.Equal(t, DrainJobCancelled, job.Status)
+		assert.Error(t, manager.Cancel(job, uncordon))
+
+		var node corev1.Node
+		require.NoError(t, cs.K8sClient.Get(context.Background(), types.NamespacedName{Name: "node-1"}, &node))
+		assert.Equal(t, !uncordon, node.Spec.Unschedulable)
+
+		// The node may be drained again once the job is done
+		next, err := manager.Start(cs, "node-1", kube.DrainOptions{})
+		require.NoError(t, err)
+		require.NoError(t, manager.Cancel(next, false))
+		waitForDrainJob(t, next)
+	}
+}
+
+func TestDrainJobCancelAfterDrain(t *testing.T) {
+	job := &DrainJob{ID: "job", Status: DrainJobRunning, drained: true, cancel: func() {}, changed: make(chan struct{})}
+	assert.Error(t, NewDrainJobManager().Cancel(job, true))
+	assert.False(t, job.cancelled)
+}
+
+func TestDrainJobManagerPrune(t *testing.T) {
+	manager := NewDrainJobManager()
+	expired := time.Now().Add(-2 * drainJobRetention)
+	recent := time.Now()
+	manager.jobs["old"] = &DrainJob{ID: "old", Cluster: "test", Node: "node-2", Status: DrainJobSucceeded, FinishedAt: &expired}
+	manager.jobs["new"] = &DrainJob{ID: "new", Cluster: "test", Node: "node-2", Status: DrainJobFailed, FinishedAt: &recent}
+
+	job, err := manager.Start(newBlockingDrainClientSet(), "node-1", kube.DrainOptions{})
+	require.NoError(t, err)
+	defer waitForDrainJob(t, job)
+	defer func() { _ = manager.Cancel(job, false) }()
+
+	_, ok := manager.Get("test", "old")
+	assert.False(t, ok)
+	_, ok = manager.Get("test", "new")
+	assert.True(t, ok)
+	_, ok = manager.Get("other", "new")
+	assert.False(t, ok)
+}
+
+func TestStreamDrainJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	job := &DrainJob{ID: "stream", Cluster: "test", Node: "node-1", Status: DrainJobRunning, Events: []kube.DrainEvent{}, changed: make(chan struct{})}
+	globalDrainJobManager.mu.Lock()
+	globalDrainJobManager.jobs[job.ID] = job
+	globalDrainJobManager.mu.Unlock()
+	defer func() {
+		globalDrainJobManager.mu.Lock()
+		delete(globalDrainJobManager.jobs, job.ID)
+		globalDrainJobManager.mu.Unlock()
+	}()
+	job.addEvent(kube.DrainEvent{Type: kube.DrainEventCordoned, Node: "node-1"})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		job.addEvent(kube.DrainEvent{Type: kube.DrainEventEvicted, Node: "node-1", Pod: "web"})
+		job.finish(&kube.DrainResult{Node: "node-1", Success: true}, nil)
+	}()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/nodes/_all/node-1/drain/jobs/stream/stream", nil)
+	c.Params = gin.Params{{Key: "name", Value: "node-1"}, {Key: "jobId", Value: "stream"}}
+	c.Set("cluster", &cluster.ClientSet{Name: "test"})
+	(&NodeHandler{}).StreamDrainJob(c)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "event: connected\n")
+	assert.Contains(t, body, "event: cordoned\n")
+	assert.Contains(t, body, "event: evicted\n")
+	assert.Contains(t, body, `"status":"succeeded"`)
+	assert.Contains(t, body, "event: close\n")
+	assert.Less(t, strings.Index(body, "event: cordoned"), strings.Index(body, "event: evicted"))
+}
@@ -377,17 +377,18 @@ func formatDuration(d time.Duration) string {
 	}
 }
 
-// DrainNode drains a node by evicting all pods
+// DrainNode starts a background job that cordons the node and evicts all pods
 func (h *NodeHandler) DrainNode(c *gin.Context) {
 	nodeName := c.Param("name")
 	ctx := c.Request.Context()
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
 	// Parse the request body for drain options
 	var drainRequest struct {
-		Force            bool `json:"force" binding:"required"`
+		Force            bool `json:"force"`
 		GracePeriod      int  `json:"gracePeriod" binding:"min=0"`
 		DeleteLocal      bool `json:"deleteLocalData"`
 		IgnoreDaemonsets bool `json:"ignoreDaemonsets"`
+		Timeout          int  `json:"timeout" binding:"min=0"` // seconds to wait for evictions
 	}
 
 	if err := c.ShouldBindJSON(&drainRequest); err != nil {
@@ -406,22 +407,27 @@ func (h *NodeHandler) DrainNode(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implement actual drain logic
-	// For now, we'll simulate the drain operation
-	// In a real implementation, you would:
-	// 1. Mark the node as unschedulable (cordon)
-	// 2. Evict all pods from the node
-	// 3. Handle daemonsets appropriately
-	// 4. Wait for pods to be evicted or force delete them
+	job, err := globalDrainJobManager.Start(cs, nodeName, kube.DrainOptions{
+		Force:            drainRequest.Force,
+		GracePeriod:      drainRequest.GracePeriod,
+		DeleteLocalData:  drainRequest.DeleteLocal,
+		IgnoreDaemonsets: drainRequest.IgnoreDaemonsets,
+		Timeout:          time.Duration(drainRequest.Timeout) * time.Second,
+	})
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "jobId": job.ID})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusAccepted, gin.H{
 		"message": fmt.Sprintf("Node %s drain operation initiated", nodeName),
 		"node":    nodeName,
+		"jobId":   job.ID,
 		"options": drainRequest,
 	})
 }
 
-func (h *NodeHandler) markNodeSchedulable(ctx context.Context, client *kube.K8sClient, nodeName string, schedulable bool) error {
+func markNodeSchedulable(ctx context.Context, client *kube.K8sClient, nodeName string, schedulable bool) error {
 	// Get the current node
 	var node corev1.Node
 	if err := client.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
@@ -440,7 +446,7 @@ func (h *NodeHandler) CordonNode(c *gin.Context) {
 	ctx := c.Request.Context()
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
 
-	if err := h.markNodeSchedulable(ctx, cs.K8sClient, nodeName, false); err != nil {
+	if err := markNodeSchedulable(ctx, cs.K8sClient, nodeName, false); err != nil {
 		if errors.IsNotFound(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Node not found"})
 			return
@@ -461,7 +467,7 @@ func (h *NodeHandler) UncordonNode(c *gin.Context) {
 	ctx := c.Request.Context()
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
 
-	if err := h.markNodeSchedulable(ctx, cs.K8sClient, nodeName, true); err != nil {
+	if err := markNodeSchedulable(ctx, cs.K8sClient, nodeName, true); err != nil {
 		if errors.IsNotFound(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Node not found"})
 			return
@@ -597,6 +603,10 @@ func (h *NodeHandler) registerCustomRoutes(group *gin.RouterGroup) {
 	group.GET("/_all/details", h.GetNodesWithDetails)
 	group.GET("/_all/:name/details", h.GetNodeDetails)
 	group.POST("/_all/:name/drain", h.DrainNode)
+	group.GET("/_all/:name/drain/jobs", h.ListDrainJobs)
+	group.GET("/_all/:name/drain/jobs/:jobId", h.GetDrainJob)
+	group.GET("/_all/:name/drain/jobs/:jobId/stream", h.StreamDrainJob)
+	group.DELETE("/_all/:name/drain/jobs/:jobId", h.CancelDrainJob)
 	group.POST("/_all/:name/cordon", h.CordonNode)
 	group.POST("/_all/:name/uncordon", h.UncordonNode)
 	group.POST("/_all/:name/taint", h.TaintNode)
@@ -0,0 +1,456 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmezard/go-difflib/difflib"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/zxh326/kite/pkg/cluster"
+)
+
+const (
+	revisionAnnotation    = "deployment.kubernetes.io/revision"
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+// Rollout states reported by RolloutStatusOf
+const (
+	RolloutProgressing = "progressing"
+	RolloutStalled     = "stalled"
+	RolloutCompleted   = "completed"
+	RolloutPaused      = "paused"
+)
+
+var (
+	// ErrRevisionNotFound is returned for revisions a workload doesn't have
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrRolloutPaused is returned when rolling back a paused deployment
+	ErrRolloutPaused = errors.New("deployment is paused, resume it before rolling back")
+)
+
+// historyResources have their revisions kept in ReplicaSets or
+// ControllerRevisions
+var historyResources = []string{"deployments", "statefulsets", "daemonsets"}
+
+// WorkloadRevision is a revision of the pod template of a workload, read
+// from a ReplicaSet for deployments and a ControllerRevision otherwise
+type WorkloadRevision struct {
+	Revision    int64     `json:"revision"`
+	Name        string    `json:"name"`
+	ChangeCause string    `json:"changeCause,omitempty"`
+	Images      []string  `json:"images"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Current     bool      `json:"current"`
+
+	template corev1.PodTemplateSpec
+}
+
+// RolloutStatus summarizes the progress of the latest rollout of a workload
+type RolloutStatus struct {
+	Status            string `json:"status"`
+	Reason            string `json:"reason,omitempty"`
+	Message           string `json:"message,omitempty"`
+	Replicas          int32  `json:"replicas"`
+	UpdatedReplicas   int32  `json:"updatedReplicas"`
+	ReadyReplicas     int32  `json:"readyReplicas"`
+	AvailableReplicas int32  `json:"availableReplicas"`
+}
+
+func getHistoryWorkload(ctx context.Context, cs *cluster.ClientSet, resource, namespace, name string) (client.Object, error) {
+	var obj client.Object
+	switch resource {
+	case "deployments":
+		obj = &appsv1.Deployment{}
+	case "statefulsets":
+		obj = &appsv1.StatefulSet{}
+	case "daemonsets":
+		obj = &appsv1.DaemonSet{}
+	default:
+		return nil, fmt.Errorf("rollout history is not supported for %s", resource)
+	}
+	if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// listRevisions returns the revisions of a workload, oldest first
+func listRevisions(ctx context.Context, cs *cluster.ClientSet, obj client.Object) ([]WorkloadRevision, error) {
+	var selector *metav1.LabelSelector
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		selector = w.Spec.Selector
+	case *appsv1.StatefulSet:
+		selector = w.Spec.Selector
+	case *appsv1.DaemonSet:
+		selector = w.Spec.Selector
+	}
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	opts := []client.ListOption{client.InNamespace(obj.GetNamespace()), client.MatchingLabelsSelector{Selector: labelSelector}}
+
+	var revisions []WorkloadRevision
+	if deployment, ok := obj.(*appsv1.Deployment); ok {
+		var replicaSets appsv1.ReplicaSetList
+		if err := cs.K8sClient.List(ctx, &replicaSets, opts...); err != nil {
+			return nil, err
+		}
+		for i := range replicaSets.Items {
+			rs := &replicaSets.Items[i]
+			if !metav1.IsControlledBy(rs, deployment) {
+				continue
+			}
+			number, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+			if err != nil {
+				continue
+			}
+			// The hash label is added by the deployment controller, it isn't
+			// part of the deployment's template
+			template := *rs.Spec.Template.DeepCopy()
+			delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+			revisions = append(revisions, newWorkloadRevision(number, rs.ObjectMeta, template,
+				rs.Annotations[revisionAnnotation] == deployment.Annotations[revisionAnnotation]))
+		}
+	} else {
+		var controllerRevisions appsv1.ControllerRevisionList
+		if err := cs.K8sClient.List(ctx, &controllerRevisions, opts...); err != nil {
+			return nil, err
+		}
+		for i := range controllerRevisions.Items {
+			cr := &controllerRevisions.Items[i]
+			if !metav1.IsControlledBy(cr, obj) {
+				continue
+			}
+			// Revisions hold a patch replacing the template of the spec
+			var data struct {
+				Spec struct {
+					Template corev1.PodTemplateSpec `json:"template"`
+				} `json:"spec"`
+			}
+			if err := json.Unmarshal(cr.Data.Raw, &data); err != nil {
+				return nil, fmt.Errorf("invalid controller revision %s: %w", cr.Name, err)
+			}
+			current := false
+			if sts, ok := obj.(*appsv1.StatefulSet); ok {
+				current = cr.Name == sts.Status.UpdateRevision
+			}
+			revisions = append(revisions, newWorkloadRevision(cr.Revision, cr.ObjectMeta, data.Spec.Template, current))
+		}
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	// DaemonSets don't report their revision, the controller always rolls
+	// out the latest one
+	if _, ok := obj.(*appsv1.DaemonSet); ok && len(revisions) > 0 {
+		revisions[len(revisions)-1].Current = true
+	}
+	return revisions, nil
+}
+
+func newWorkloadRevision(number int64, meta metav1.ObjectMeta, template corev1.PodTemplateSpec, current bool) WorkloadRevision {
+	images := make([]string, 0, len(template.Spec.Containers))
+	for _, container := range template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	return WorkloadRevision{
+		Revision:    number,
+		Name:        meta.Name,
+		ChangeCause: meta.Annotations[changeCauseAnnotation],
+		Images:      images,
+		CreatedAt:   meta.CreationTimestamp.Time,
+		Current:     current,
+		template:    template,
+	}
+}
+
+// findRevision returns a revision by number, 0 is the revision before the
+// current one
+func findRevision(revisions []WorkloadRevision, number int64) (*WorkloadRevision, error) {
+	if number == 0 {
+		for i := len(revisions) - 1; i > 0; i-- {
+			if revisions[i].Current {
+				return &revisions[i-1], nil
+			}
+		}
+		return nil, fmt.Errorf("%w: there is no previous revision", ErrRevisionNotFound)
+	}
+	for i := range revisions {
+		if revisions[i].Revision == number {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrRevisionNotFound, number)
+}
+
+// diffRevisions returns a unified diff of the YAML of two pod templates
+func diffRevisions(from, to *WorkloadRevision) (string, error) {
+	a, err := yaml.Marshal(from.template)
+	if err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(to.template)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: fmt.Sprintf("revision %d", from.Revision),
+		ToFile:   fmt.Sprintf("revision %d", to.Revision),
+		Context:  3,
+	})
+}
+
+// RollbackWorkload restores the pod template of a revision, 0 rolls back to
+// the previous one. It returns the revision rolled back to and false when
+// the workload already runs its template.
+func RollbackWorkload(ctx context.Context, cs *cluster.ClientSet, resource, namespace, name string, number int64) (*WorkloadRevision, bool, error) {
+	var (
+		target  *WorkloadRevision
+		changed bool
+	)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := getHistoryWorkload(ctx, cs, resource, namespace, name)
+		if err != nil {
+			return err
+		}
+		revisions, err := listRevisions(ctx, cs, obj)
+		if err != nil {
+			return err
+		}
+		if target, err = findRevision(revisions, number); err != nil {
+			return err
+		}
+		if deployment, ok := obj.(*appsv1.Deployment); ok && deployment.Spec.Paused {
+			return ErrRolloutPaused
+		}
+		template := podTemplateOf(obj)
+		if changed = !equality.Semantic.DeepEqual(*template, target.template); !changed {
+			return nil
+		}
+		*template = *target.template.DeepCopy()
+		if deployment, ok := obj.(*appsv1.Deployment); ok {
+			// Like kubectl, the deployment takes the change cause of the revision
+			if target.ChangeCause != "" {
+				metav1.SetMetaDataAnnotation(&deployment.ObjectMeta, changeCauseAnnotation, target.ChangeCause)
+			} else {
+				delete(deployment.Annotations, changeCauseAnnotation)
+			}
+		}
+		return cs.K8sClient.Update(ctx, obj)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return target, changed, nil
+}
+
+// RolloutStatusOf reports whether the rollout of a workload is progressing,
+// stalled, completed or paused
+func RolloutStatusOf(obj client.Object) (*RolloutStatus, error) {
+	status := &RolloutStatus{Status: RolloutProgressing}
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		status.Replicas = w.Status.Replicas
+		status.UpdatedReplicas = w.Status.UpdatedReplicas
+		status.ReadyReplicas = w.Status.ReadyReplicas
+		status.AvailableReplicas = w.Status.AvailableReplicas
+		for _, cond := range w.Status.Conditions {
+			switch {
+			case cond.Type == appsv1.DeploymentProgressing:
+				status.Reason, status.Message = cond.Reason, cond.Message
+			case cond.Type == appsv1.DeploymentReplicaFailure && cond.Status == corev1.ConditionTrue:
+				status.Status, status.Reason, status.Message = RolloutStalled, cond.Reason, cond.Message
+				return status, nil
+			}
+		}
+		if w.Spec.Paused {
+			status.Status = RolloutPaused
+			return status, nil
+		}
+		if status.Reason == "ProgressDeadlineExceeded" {
+			status.Status = RolloutStalled
+			return status, nil
+		}
+	case *appsv1.StatefulSet:
+		status.Replicas = w.Status.Replicas
+		status.UpdatedReplicas = w.Status.UpdatedReplicas
+		status.ReadyReplicas = w.Status.ReadyReplicas
+		status.AvailableReplicas = w.Status.AvailableReplicas
+	case *appsv1.DaemonSet:
+		status.Replicas = w.Status.DesiredNumberScheduled
+		status.UpdatedReplicas = w.Status.UpdatedNumberScheduled
+		status.ReadyReplicas = w.Status.NumberReady
+		status.AvailableReplicas = w.Status.NumberAvailable
+	}
+	done, err := rolloutComplete(obj)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		status.Status = RolloutCompleted
+	}
+	return status, nil
+}
+
+// RolloutHandler serves the revision history, rollback and rollout status of
+// deployments, statefulsets and daemonsets
+type RolloutHandler struct{}
+
+func (h *RolloutHandler) getWorkload(c *gin.Context) (client.Object, *cluster.ClientSet, bool) {
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	obj, err := getHistoryWorkload(c.Request.Context(), cs, c.GetString("resource"), c.Param("namespace"), c.Param("name"))
+	if apierrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	return obj, cs, true
+}
+
+// ListHistory lists the revisions of a workload, oldest first
+func (h *RolloutHandler) ListHistory(c *gin.Context) {
+	obj, cs, ok := h.getWorkload(c)
+	if !ok {
+		return
+	}
+	revisions, err := listRevisions(c.Request.Context(), cs, obj)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list revisions: " + err.Error()})
+		return
+	}
+	if revisions == nil {
+		revisions = []WorkloadRevision{}
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// DiffRevisions diffs the pod templates of the from and to revisions, to
+// defaults to the current revision
+func (h *RolloutHandler) DiffRevisions(c *gin.Context) {
+	from, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from parameter, expected a revision number"})
+		return
+	}
+	var to int64
+	if v := c.Query("to"); v != "" {
+		if to, err = strconv.ParseInt(v, 10, 64); err != nil || to <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to parameter, expected a revision number"})
+			return
+		}
+	}
+
+	obj, cs, ok := h.getWorkload(c)
+	if !ok {
+		return
+	}
+	revisions, err := listRevisions(c.Request.Context(), cs, obj)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list revisions: " + err.Error()})
+		return
+	}
+	fromRevision, err := findRevision(revisions, from)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	var toRevision *WorkloadRevision
+	if to == 0 {
+		for i := range revisions {
+			if revisions[i].Current {
+				toRevision = &revisions[i]
+			}
+		}
+		if toRevision == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "current revision not found"})
+			return
+		}
+	} else if toRevision, err = findRevision(revisions, to); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	diff, err := diffRevisions(fromRevision, toRevision)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from": fromRevision,
+		"to":   toRevision,
+		"diff": diff,
+	})
+}
+
+// Rollback restores the pod template of a revision
+func (h *RolloutHandler) Rollback(c *gin.Context) {
+	var req struct {
+		// Revision 0 rolls back to the previous revision
+		Revision int64 `json:"revision" binding:"min=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	revision, changed, err := RollbackWorkload(c.Request.Context(), cs, c.GetString("resource"), c.Param("namespace"), c.Param("name"), req.Revision)
+	switch {
+	case apierrors.IsNotFound(err), errors.Is(err, ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrRolloutPaused):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back: " + err.Error()})
+		return
+	}
+	message := fmt.Sprintf("Rolled back to revision %d", revision.Revision)
+	if !changed {
+		message = fmt.Sprintf("Already running the template of revision %d", revision.Revision)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"revision": revision,
+		"changed":  changed,
+	})
+}
+
+// GetRolloutStatus reports the state of the latest rollout of a workload
+func (h *RolloutHandler) GetRolloutStatus(c *gin.Context) {
+	obj, _, ok := h.getWorkload(c)
+	if !ok {
+		return
+	}
+	status, err := RolloutStatusOf(obj)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
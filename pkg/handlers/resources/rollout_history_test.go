@@ -0,0 +1,159 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+func podTemplate(image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: image}}},
+	}
+}
+
+func TestDeploymentHistory(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid", Annotations: map[string]string{revisionAnnotation: "2"}},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: podTemplate("web:v2"),
+		},
+	}
+	owner := *metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	replicaSet := func(name, revision, image, cause string) *appsv1.ReplicaSet {
+		template := podTemplate(image)
+		template.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = name
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Labels:          template.Labels,
+				Annotations:     map[string]string{revisionAnnotation: revision, changeCauseAnnotation: cause},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Spec: appsv1.ReplicaSetSpec{Template: template},
+		}
+	}
+	cs := &cluster.ClientSet{K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().WithObjects(
+		deployment,
+		replicaSet("web-1", "1", "web:v1", "initial release"),
+		replicaSet("web-2", "2", "web:v2", "bump to v2"),
+	).Build()}}
+	ctx := context.Background()
+
+	obj, err := getHistoryWorkload(ctx, cs, "deployments", "default", "web")
+	require.NoError(t, err)
+	revisions, err := listRevisions(ctx, cs, obj)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, int64(1), revisions[0].Revision)
+	assert.Equal(t, "initial release", revisions[0].ChangeCause)
+	assert.Equal(t, []string{"web:v1"}, revisions[0].Images)
+	assert.False(t, revisions[0].Current)
+	assert.True(t, revisions[1].Current)
+
+	diff, err := diffRevisions(&revisions[0], &revisions[1])
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-  - image: web:v1\n+  - image: web:v2\n")
+	assert.NotContains(t, diff, appsv1.DefaultDeploymentUniqueLabelKey)
+
+	revision, changed, err := RollbackWorkload(ctx, cs, "deployments", "default", "web", 0)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, int64(1), revision.Revision)
+	var got appsv1.Deployment
+	require.NoError(t, cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "web"}, &got))
+	assert.Equal(t, "web:v1", got.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "initial release", got.Annotations[changeCauseAnnotation])
+
+	_, _, err = RollbackWorkload(ctx, cs, "deployments", "default", "web", 7)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+}
+
+func TestStatefulSetHistory(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: "db-uid"},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: podTemplate("db:v2"),
+		},
+		Status: appsv1.StatefulSetStatus{UpdateRevision: "db-2"},
+	}
+	owner := *metav1.NewControllerRef(sts, appsv1.SchemeGroupVersion.WithKind("StatefulSet"))
+	controllerRevision := func(name string, revision int64, image string) *appsv1.ControllerRevision {
+		data, err := json.Marshal(map[string]any{"spec": map[string]any{"template": podTemplate(image)}})
+		require.NoError(t, err)
+		return &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Labels:          map[string]string{"app": "web"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Data:     runtime.RawExtension{Raw: data},
+			Revision: revision,
+		}
+	}
+	cs := &cluster.ClientSet{K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().WithObjects(
+		sts, controllerRevision("db-1", 1, "db:v1"), controllerRevision("db-2", 2, "db:v2"),
+	).Build()}}
+	ctx := context.Background()
+
+	obj, err := getHistoryWorkload(ctx, cs, "statefulsets", "default", "db")
+	require.NoError(t, err)
+	revisions, err := listRevisions(ctx, cs, obj)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, []string{"db:v1"}, revisions[0].Images)
+	assert.True(t, revisions[1].Current)
+
+	_, changed, err := RollbackWorkload(ctx, cs, "statefulsets", "default", "db", 2)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	_, changed, err = RollbackWorkload(ctx, cs, "statefulsets", "default", "db", 1)
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestRolloutStatusOf(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+		Status: appsv1.DeploymentStatus{
+			Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2,
+			Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Reason: "ReplicaSetUpdated"}},
+		},
+	}
+	status, err := RolloutStatusOf(deployment)
+	require.NoError(t, err)
+	assert.Equal(t, RolloutProgressing, status.Status)
+
+	deployment.Status.Conditions[0].Reason = "ProgressDeadlineExceeded"
+	status, err = RolloutStatusOf(deployment)
+	require.NoError(t, err)
+	assert.Equal(t, RolloutStalled, status.Status)
+
+	deployment.Status = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+	status, err = RolloutStatusOf(deployment)
+	require.NoError(t, err)
+	assert.Equal(t, RolloutCompleted, status.Status)
+
+	deployment.Spec.Paused = true
+	status, err = RolloutStatusOf(deployment)
+	require.NoError(t, err)
+	assert.Equal(t, RolloutPaused, status.Status)
+}
@@ -0,0 +1,563 @@
+package resources
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/utils"
+)
+
+// SearchFunc searches one resource type in the cluster of the context
+type SearchFunc = func(c *gin.Context, query string, limit int64) ([]common.SearchResult, error)
+
+const (
+	searchFieldName       = ""
+	searchFieldLabel      = "label"
+	searchFieldAnnotation = "annotation"
+	// searchFieldMetadata matches key=value against labels and annotations
+	searchFieldMetadata = "metadata"
+	searchFieldImage    = "image"
+	searchFieldNode     = "node"
+	searchFieldIP       = "ip"
+)
+
+// searchFieldPrefixes restrict a term to one field, like image:nginx
+var searchFieldPrefixes = map[string]string{
+	"label":      searchFieldLabel,
+	"l":          searchFieldLabel,
+	"annotation": searchFieldAnnotation,
+	"anno":       searchFieldAnnotation,
+	"a":          searchFieldAnnotation,
+	"image":      searchFieldImage,
+	"img":        searchFieldImage,
+	"node":       searchFieldNode,
+	"ip":         searchFieldIP,
+}
+
+// searchQuery is a parsed search term. Plain text matches names, and images,
+// node names and IPs of the objects that have them; key=value matches labels
+// and annotations; a field prefix like label:, anno:, image:, node: or ip:
+// restricts the match to that field.
+type searchQuery struct {
+	field string
+	key   string
+	// value is lower case for substring matches
+	value    string
+	hasValue bool
+}
+
+func parseSearchQuery(q string) searchQuery {
+	q = strings.TrimSpace(q)
+	query := searchQuery{field: searchFieldName}
+	if prefix, rest, ok := strings.Cut(q, ":"); ok {
+		if field, known := searchFieldPrefixes[strings.ToLower(prefix)]; known {
+			query.field, q = field, strings.TrimSpace(rest)
+		}
+	}
+
+	switch query.field {
+	case searchFieldLabel, searchFieldAnnotation:
+		query.key, query.value, query.hasValue = strings.Cut(q, "=")
+	case searchFieldName:
+		if key, value, ok := strings.Cut(q, "="); ok && key != "" {
+			query.field, query.key, query.value, query.hasValue = searchFieldMetadata, key, value, true
+		} else {
+			query.value = strings.ToLower(q)
+		}
+	default:
+		query.value = strings.ToLower(q)
+	}
+	query.key = strings.TrimSpace(query.key)
+	if query.hasValue {
+		query.value = strings.TrimSpace(query.value)
+	}
+	return query
+}
+
+// valid rejects terms too short to be useful, plain names need 3 characters
+func (q searchQuery) valid() bool {
+	switch q.field {
+	case searchFieldName:
+		return len(q.value) >= 3
+	case searchFieldLabel, searchFieldAnnotation, searchFieldMetadata:
+		return q.key != ""
+	default:
+		return q.value != ""
+	}
+}
+
+// Scores rank search results, higher is better
+const (
+	scoreExact     = 100
+	scorePrefix    = 80
+	scoreToken     = 70
+	scoreSubstring = 60
+	scoreTokens    = 50
+	scoreField     = 40
+	scoreFuzzy     = 20
+)
+
+// match reports whether obj matches with a score for ranking, and which
+// field matched when it isn't the name
+func (q searchQuery) match(obj client.Object, fields *searchFields) (string, int, bool) {
+	var (
+		match string
+		ok    bool
+	)
+	switch q.field {
+	case searchFieldLabel:
+		match, ok = matchMetadata("label", obj.GetLabels(), q)
+	case searchFieldAnnotation:
+		match, ok = matchMetadata("annotation", obj.GetAnnotations(), q)
+	case searchFieldMetadata:
+		if match, ok = matchMetadata("label", obj.GetLabels(), q); !ok {
+			match, ok = matchMetadata("annotation", obj.GetAnnotations(), q)
+		}
+	case searchFieldImage:
+		match, ok = matchAny("image", fields.images, q.value, strings.Contains)
+	case searchFieldNode:
+		match, ok = matchAny("node", []string{fields.nodeName}, q.value, strings.Contains)
+	case searchFieldIP:
+		match, ok = matchAny("ip", fields.ips, q.value, strings.HasPrefix)
+	default:
+		score := nameScore(fields, q.value)
+		if score > scoreFuzzy {
+			return "", score, true
+		}
+		if net.ParseIP(q.value) != nil {
+			match, ok = matchAny("ip", fields.ips, q.value, func(ip, value string) bool { return ip == value })
+		} else if match, ok = matchAny("image", fields.images, q.value, strings.Contains); !ok {
+			match, ok = matchAny("node", []string{fields.nodeName}, q.value, func(node, value string) bool { return node == value })
+		}
+		// Fuzzy name matches rank below exact field matches
+		if !ok && score > 0 {
+			return "", score, true
+		}
+	}
+	if !ok {
+		return "", 0, false
+	}
+	return match, scoreField, true
+}
+
+// nameScore ranks how well the name matches: exact, prefix, a whole name
+// token, substring, every query token prefixing a name token, and finally a
+// fuzzy match of the characters in order or of a token with one typo
+func nameScore(fields *searchFields, query string) int {
+	name := fields.name
+	switch {
+	case name == query:
+		return scoreExact
+	case strings.HasPrefix(name, query):
+		return scorePrefix
+	case slices.Contains(fields.tokens, query):
+		return scoreToken
+	case strings.Contains(name, query):
+		return scoreSubstring
+	}
+
+	queryTokens := searchTokens(query)
+	if len(queryTokens) > 1 && allTokensPrefix(queryTokens, fields.tokens) {
+		return scoreTokens
+	}
+	if len(query) < 3 {
+		return 0
+	}
+	// Short queries are in order somewhere in most long names, so they need
+	// a tighter window to count
+	maxSpan := 2 * len(query)
+	if len(query) >= 4 {
+		maxSpan = 4 * len(query)
+	}
+	if span, ok := subsequenceSpan(name, query); ok && span <= maxSpan {
+		// Tighter matches rank higher
+		return scoreFuzzy - min((span-len(query))/2, scoreFuzzy-1)
+	}
+	if len(query) >= 4 {
+		for _, token := range fields.tokens {
+			if oneTypoApart(token, query) {
+				return scoreFuzzy / 2
+			}
+		}
+	}
+	return 0
+}
+
+// searchTokens splits names like ingress-nginx-controller or kube.system
+func searchTokens(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == '/' || r == ' '
+	})
+}
+
+func allTokensPrefix(queryTokens, tokens []string) bool {
+	used := make([]bool, len(tokens))
+	for _, qt := range queryTokens {
+		found := false
+		for i, token := range tokens {
+			if !used[i] && strings.HasPrefix(token, qt) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// subsequenceSpan finds the characters of query in order in s and returns
+// the length of the shortest window containing them
+func subsequenceSpan(s, query string) (int, bool) {
+	best := -1
+	for start := 0; start < len(s); start++ {
+		if s[start] != query[0] {
+			continue
+		}
+		i, j := start, 0
+		for i < len(s) && j < len(query) {
+			if s[i] == query[j] {
+				j++
+			}
+			i++
+		}
+		if j < len(query) {
+			break
+		}
+		if span := i - start; best < 0 || span < best {
+			best = span
+		}
+	}
+	return best, best >= 0
+}
+
+// oneTypoApart reports whether a and b differ by at most one insertion,
+// deletion, substitution or swap of adjacent characters
+func oneTypoApart(a, b string) bool {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if len(a)-len(b) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(b) && a[i] == b[i] {
+		i++
+	}
+	if i == len(b) {
+		return true
+	}
+	if len(a) != len(b) {
+		return a[i+1:] == b[i:]
+	}
+	if a[i+1:] == b[i+1:] {
+		return true
+	}
+	return i+1 < len(a) && a[i] == b[i+1] && a[i+1] == b[i] && a[i+2:] == b[i+2:]
+}
+
+func matchMetadata(field string, values map[string]string, q searchQuery) (string, bool) {
+	value, ok := values[q.key]
+	if !ok || (q.hasValue && value != q.value) {
+		return "", false
+	}
+	return fmt.Sprintf("%s %s=%s", field, q.key, value), true
+}
+
+func matchAny(field string, values []string, value string, matches func(s, value string) bool) (string, bool) {
+	for _, v := range values {
+		if v != "" && matches(strings.ToLower(v), value) {
+			return field + " " + v, true
+		}
+	}
+	return "", false
+}
+
+// searchFields are the fields besides labels and annotations that searches
+// look at, extracted once per object
+type searchFields struct {
+	// name is lower case, tokens are its parts split on separators
+	name     string
+	tokens   []string
+	images   []string
+	nodeName string
+	ips      []string
+}
+
+// podSpecPaths locate the pod spec of pods, workloads and cronjobs
+var podSpecPaths = [][]string{
+	{"spec"},
+	{"spec", "template", "spec"},
+	{"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// ipPaths hold the IPs of pods, services and nodes
+var ipPaths = [][]string{
+	{"status", "podIP"},
+	{"status", "hostIP"},
+	{"spec", "clusterIP"},
+}
+
+var ipListPaths = []struct {
+	path []string
+	key  string
+}{
+	{[]string{"status", "podIPs"}, "ip"},
+	{[]string{"spec", "clusterIPs"}, ""},
+	{[]string{"spec", "externalIPs"}, ""},
+	{[]string{"status", "loadBalancer", "ingress"}, "ip"},
+	{[]string{"status", "addresses"}, "address"},
+}
+
+// extractSearchFields finds images, node names and IPs generically, so typed
+// objects and custom resources with a pod template are handled alike
+func extractSearchFields(obj client.Object) *searchFields {
+	name := strings.ToLower(obj.GetName())
+	fields := &searchFields{name: name, tokens: searchTokens(name)}
+	var content map[string]any
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = u.UnstructuredContent()
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return fields
+		}
+	}
+
+	for _, path := range podSpecPaths {
+		spec, found, _ := unstructured.NestedMap(content, path...)
+		if !found {
+			continue
+		}
+		for _, key := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			containers, _, _ := unstructured.NestedSlice(spec, key)
+			for _, container := range containers {
+				if c, ok := container.(map[string]any); ok {
+					if image, ok := c["image"].(string); ok {
+						fields.images = append(fields.images, image)
+					}
+				}
+			}
+		}
+		if nodeName, ok := spec["nodeName"].(string); ok {
+			fields.nodeName = nodeName
+		}
+	}
+
+	seen := map[string]bool{}
+	addIP := func(ip string) {
+		if ip != "" && ip != "None" && !seen[ip] {
+			seen[ip] = true
+			fields.ips = append(fields.ips, ip)
+		}
+	}
+	for _, path := range ipPaths {
+		if ip, found, _ := unstructured.NestedString(content, path...); found {
+			addIP(ip)
+		}
+	}
+	for _, list := range ipListPaths {
+		items, _, _ := unstructured.NestedSlice(content, list.path...)
+		for _, item := range items {
+			if list.key == "" {
+				if ip, ok := item.(string); ok {
+					addIP(ip)
+				}
+			} else if m, ok := item.(map[string]any); ok {
+				if ip, ok := m[list.key].(string); ok && net.ParseIP(ip) != nil {
+					addIP(ip)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// searchObjects matches objects against the query, best matches first
+func searchObjects(resourceType string, objects []client.Object, q string, limit int64) []common.SearchResult {
+	query := parseSearchQuery(q)
+	if !query.valid() {
+		return nil
+	}
+	results := make([]common.SearchResult, 0)
+	for _, obj := range objects {
+		if result, ok := searchObject(resourceType, obj, extractSearchFields(obj), query); ok {
+			results = append(results, result)
+		}
+	}
+	return rankResults(results, limit)
+}
+
+func searchObject(resourceType string, obj client.Object, fields *searchFields, query searchQuery) (common.SearchResult, bool) {
+	match, score, ok := query.match(obj, fields)
+	if !ok {
+		return common.SearchResult{}, false
+	}
+	return common.SearchResult{
+		ID:           string(obj.GetUID()),
+		Name:         obj.GetName(),
+		Namespace:    obj.GetNamespace(),
+		ResourceType: resourceType,
+		CreatedAt:    obj.GetCreationTimestamp().String(),
+		Match:        match,
+		Score:        score,
+	}, true
+}
+
+// searchUnstructured searches a resource that has no typed handler, like a CRD
+func searchUnstructured(c *gin.Context, gvk schema.GroupVersionKind, resourceType, q string, limit int64) ([]common.SearchResult, error) {
+	if !parseSearchQuery(q).valid() {
+		return nil, nil
+	}
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := cs.K8sClient.List(c.Request.Context(), list); err != nil {
+		return nil, err
+	}
+	objects := make([]client.Object, 0, len(list.Items))
+	for i := range list.Items {
+		objects = append(objects, &list.Items[i])
+	}
+	return searchObjects(resourceType, objects, q, limit), nil
+}
+
+// discoveredResource is a resource found by discovery, searchable by its
+// name, singular name, kind and shortNames
+type discoveredResource struct {
+	gvk      schema.GroupVersionKind
+	resource string
+}
+
+var (
+	// searchAliases caches the alias table built from discovery per cluster
+	searchAliases = expirable.NewLRU[string, map[string]discoveredResource](50, nil, 10*time.Minute)
+
+	handlerGroupKindsOnce sync.Once
+	handlerGroupKinds     map[schema.GroupKind]string
+)
+
+// discoverSearchAliases maps every alias of the served resources to the
+// preferred version. Core groups come first, so they win on shared aliases.
+func discoverSearchAliases(cs *cluster.ClientSet) map[string]discoveredResource {
+	if aliases, ok := searchAliases.Get(cs.Name); ok {
+		return aliases
+	}
+	aliases := map[string]discoveredResource{}
+	lists, err := cs.K8sClient.ClientSet.Discovery().ServerPreferredResources()
+	if err != nil {
+		// Partial results are still usable when some API groups are down
+		klog.V(2).Infof("Failed to discover all resources of cluster %s: %v", cs.Name, err)
+	}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !hasVerb(r, "list") {
+				continue
+			}
+			resource := discoveredResource{gvk: gv.WithKind(r.Kind), resource: r.Name}
+			for _, alias := range append([]string{r.Name, r.SingularName, strings.ToLower(r.Kind)}, r.ShortNames...) {
+				if _, exists := aliases[alias]; alias != "" && !exists {
+					aliases[alias] = resource
+				}
+			}
+		}
+	}
+	if len(aliases) > 0 {
+		searchAliases.Add(cs.Name, aliases)
+	}
+	return aliases
+}
+
+func hasVerb(r metav1.APIResource, verb string) bool {
+	for _, v := range r.Verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKindHandler is implemented by handlers of custom resources without Go types
+type groupKindHandler interface {
+	groupKind() schema.GroupKind
+}
+
+// handlerForGroupKind finds the registered handler serving a group and kind
+func handlerForGroupKind(scheme *runtime.Scheme, gk schema.GroupKind) (string, bool) {
+	handlerGroupKindsOnce.Do(func() {
+		handlerGroupKinds = map[schema.GroupKind]string{}
+		for name, handler := range handlers {
+			switch h := handler.(type) {
+			case watchableHandler:
+				if gvk, err := apiutil.GVKForObject(h.newObject(), scheme); err == nil {
+					handlerGroupKinds[gvk.GroupKind()] = name
+				}
+			case groupKindHandler:
+				handlerGroupKinds[h.groupKind()] = name
+			}
+		}
+	})
+	name, ok := handlerGroupKinds[gk]
+	return name, ok
+}
+
+// ResolveSearch picks the search functions for a query. A leading resource
+// alias, like "po nginx", restricts the search to that resource: built-in
+// aliases, handler names and the names, kinds and shortNames discovered in
+// the cluster all work, so every handler and CRD is searchable. Without one,
+// the resources searched by default are used. It returns the rest of the query.
+func ResolveSearch(c *gin.Context, query string) (map[string]SearchFunc, string) {
+	query = strings.TrimSpace(query)
+	alias, rest, ok := strings.Cut(query, " ")
+	if !ok {
+		return SearchFuncs, query
+	}
+	rest = strings.TrimSpace(rest)
+	if resource, q := utils.GuessSearchResources(query); resource != "all" {
+		if handler, ok := handlers[resource]; ok {
+			return map[string]SearchFunc{resource: handler.Search}, q
+		}
+	}
+	alias = strings.ToLower(alias)
+	if handler, ok := handlers[alias]; ok {
+		return map[string]SearchFunc{alias: handler.Search}, rest
+	}
+
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	resource, ok := discoverSearchAliases(cs)[alias]
+	if !ok {
+		return SearchFuncs, query
+	}
+	if name, ok := handlerForGroupKind(cs.K8sClient.Scheme(), resource.gvk.GroupKind()); ok {
+		return map[string]SearchFunc{name: handlers[name].Search}, rest
+	}
+	// Custom resources are shown by their CRD name
+	resourceType := resource.resource
+	if resource.gvk.Group != "" {
+		resourceType += "." + resource.gvk.Group
+	}
+	return map[string]SearchFunc{resourceType: func(c *gin.Context, query string, limit int64) ([]common.SearchResult, error) {
+		return searchUnstructured(c, resource.gvk, resourceType, query, limit)
+	}}, rest
+}
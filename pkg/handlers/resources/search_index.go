@@ -0,0 +1,170 @@
+package resources
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+// searchIndexSyncTimeout bounds the wait for an informer on the first search
+// of a kind, searches fall back to listing when it isn't ready in time
+const searchIndexSyncTimeout = 10 * time.Second
+
+// searchIndex keeps the searchable fields of the cached objects of one
+// cluster. It is fed by informer events, so searches neither list nor convert
+// whole collections on every query. Kinds are indexed on their first search.
+type searchIndex struct {
+	client *kube.K8sClient
+
+	mu    sync.Mutex
+	kinds map[string]*indexedKind
+}
+
+type indexedKind struct {
+	registration toolscache.ResourceEventHandlerRegistration
+
+	mu      sync.RWMutex
+	entries map[string]*indexEntry
+}
+
+type indexEntry struct {
+	obj    client.Object
+	fields *searchFields
+}
+
+var (
+	searchIndexesMu sync.Mutex
+	// searchIndexes are keyed by the client, a cluster that is rebuilt after
+	// a kubeconfig change gets a new client and a new index
+	searchIndexes = map[*kube.K8sClient]*searchIndex{}
+)
+
+// searchIndexFor returns the index of a client, nil for clients without an
+// informer cache, like impersonated ones, which must search as their user
+func searchIndexFor(k *kube.K8sClient) *searchIndex {
+	done := k.Done()
+	if done == nil {
+		return nil
+	}
+	searchIndexesMu.Lock()
+	defer searchIndexesMu.Unlock()
+	if idx, ok := searchIndexes[k]; ok {
+		return idx
+	}
+	idx := &searchIndex{client: k, kinds: map[string]*indexedKind{}}
+	searchIndexes[k] = idx
+	go func() {
+		<-done
+		searchIndexesMu.Lock()
+		delete(searchIndexes, k)
+		searchIndexesMu.Unlock()
+	}()
+	return idx
+}
+
+// kind returns the index of the kind of obj, ok is false when the kind isn't
+// cached or its informer isn't synced yet
+func (idx *searchIndex) kind(ctx context.Context, obj client.Object) (*indexedKind, bool) {
+	gvk, err := idx.client.GroupVersionKindFor(obj)
+	if err != nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, searchIndexSyncTimeout)
+	defer cancel()
+
+	idx.mu.Lock()
+	k, ok := idx.kinds[gvk.String()]
+	if !ok {
+		informer, cached, err := idx.client.Informer(ctx, obj)
+		if err != nil || !cached {
+			idx.mu.Unlock()
+			if err != nil {
+				klog.V(2).Infof("Not indexing %s for search: %v", gvk.Kind, err)
+			}
+			return nil, false
+		}
+		k = &indexedKind{entries: map[string]*indexEntry{}}
+		if k.registration, err = informer.AddEventHandler(k); err != nil {
+			idx.mu.Unlock()
+			return nil, false
+		}
+		idx.kinds[gvk.String()] = k
+	}
+	idx.mu.Unlock()
+
+	// The objects already cached are replayed to the handler asynchronously
+	if !toolscache.WaitForCacheSync(ctx.Done(), k.registration.HasSynced) {
+		return nil, false
+	}
+	return k, true
+}
+
+func (k *indexedKind) OnAdd(obj any, _ bool) {
+	k.set(obj)
+}
+
+func (k *indexedKind) OnUpdate(_, obj any) {
+	k.set(obj)
+}
+
+func (k *indexedKind) OnDelete(obj any) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if o, ok := obj.(client.Object); ok {
+		k.mu.Lock()
+		delete(k.entries, objectKey(o))
+		k.mu.Unlock()
+	}
+}
+
+// set extracts the fields outside the lock, informer objects are read-only
+// so entries can keep referencing them
+func (k *indexedKind) set(obj any) {
+	o, ok := obj.(client.Object)
+	if !ok {
+		return
+	}
+	entry := &indexEntry{obj: o, fields: extractSearchFields(o)}
+	k.mu.Lock()
+	k.entries[objectKey(o)] = entry
+	k.mu.Unlock()
+}
+
+// search returns the best matches first
+func (k *indexedKind) search(resourceType string, query searchQuery, limit int64) []common.SearchResult {
+	k.mu.RLock()
+	results := make([]common.SearchResult, 0)
+	for _, entry := range k.entries {
+		if result, ok := searchObject(resourceType, entry.obj, entry.fields, query); ok {
+			results = append(results, result)
+		}
+	}
+	k.mu.RUnlock()
+	return rankResults(results, limit)
+}
+
+// rankResults sorts by score, then namespace and name, and applies the limit
+func rankResults(results []common.SearchResult, limit int64) []common.SearchResult {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Namespace != results[j].Namespace {
+			return results[i].Namespace < results[j].Namespace
+		}
+		return results[i].Name < results[j].Name
+	})
+	if limit > 0 && int64(len(results)) > limit {
+		results = results[:limit]
+	}
+	return results
+}
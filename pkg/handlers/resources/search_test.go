@@ -0,0 +1,129 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  searchQuery
+	}{
+		{"Nginx", searchQuery{field: searchFieldName, value: "nginx"}},
+		{"app=web", searchQuery{field: searchFieldMetadata, key: "app", value: "web", hasValue: true}},
+		{"label:app", searchQuery{field: searchFieldLabel, key: "app"}},
+		{"anno:team = infra", searchQuery{field: searchFieldAnnotation, key: "team", value: "infra", hasValue: true}},
+		{"image:Nginx:1.27", searchQuery{field: searchFieldImage, value: "nginx:1.27"}},
+		{"ip:10.0.", searchQuery{field: searchFieldIP, value: "10.0."}},
+		{"foo:bar", searchQuery{field: searchFieldName, value: "foo:bar"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseSearchQuery(tt.query))
+		})
+	}
+	assert.False(t, parseSearchQuery("ab").valid())
+	assert.False(t, parseSearchQuery("image:").valid())
+}
+
+func TestSearchObjects(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web-1",
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "web"},
+			Annotations: map[string]string{"team": "infra"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:   "worker-1",
+			Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+		},
+		Status: corev1.PodStatus{PodIP: "10.0.0.12", PodIPs: []corev1.PodIP{{IP: "10.0.0.12"}}},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "api", Image: "ghcr.io/acme/api:v2"}}},
+		}},
+	}
+	objects := []client.Object{pod, deployment}
+
+	tests := []struct {
+		query string
+		names []string
+		match string
+	}{
+		{"web", []string{"web-1"}, ""},
+		{"app=web", []string{"web-1"}, "label app=web"},
+		{"team=infra", []string{"web-1"}, "annotation team=infra"},
+		{"label:team=infra", nil, ""},
+		{"nginx", []string{"web-1"}, "image nginx:1.27"},
+		{"image:acme/api", []string{"api"}, "image ghcr.io/acme/api:v2"},
+		{"worker-1", []string{"web-1"}, "node worker-1"},
+		{"10.0.0.12", []string{"web-1"}, "ip 10.0.0.12"},
+		{"ip:10.0.", []string{"web-1"}, "ip 10.0.0.12"},
+		{"10.0.0.1", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results := searchObjects("workloads", objects, tt.query, 10)
+			var names []string
+			for _, result := range results {
+				names = append(names, result.Name)
+			}
+			assert.Equal(t, tt.names, names)
+			if len(results) > 0 {
+				assert.Equal(t, tt.match, results[0].Match)
+			}
+		})
+	}
+}
+
+func TestNameScoreRanking(t *testing.T) {
+	score := func(name, query string) int {
+		return nameScore(extractSearchFields(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}), query)
+	}
+	assert.Equal(t, scoreExact, score("nginx", "nginx"))
+	assert.Equal(t, scorePrefix, score("nginx-web", "nginx"))
+	assert.Equal(t, scoreToken, score("ingress-nginx-controller", "nginx"))
+	assert.Equal(t, scoreSubstring, score("mynginxapp", "nginx"))
+	assert.Equal(t, scoreTokens, score("ingress-nginx-controller", "ingress contr"))
+	assert.Greater(t, score("ingress-nginx-controller", "ingctl"), 0)
+	assert.Greater(t, score("prometheus-server", "promethues"), 0)
+	assert.Zero(t, score("coredns", "nginx"))
+}
+
+func TestIndexedKindSearch(t *testing.T) {
+	k := &indexedKind{entries: map[string]*indexEntry{}}
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	}
+	k.OnAdd(newPod("web"), true)
+	k.OnAdd(newPod("web-canary"), false)
+	k.OnAdd(newPod("api-web"), false)
+	k.OnAdd(newPod("db"), false)
+
+	names := func(limit int64) []string {
+		var names []string
+		for _, result := range k.search("pods", parseSearchQuery("web"), limit) {
+			names = append(names, result.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"web", "web-canary", "api-web"}, names(0))
+	assert.Equal(t, []string{"web", "web-canary"}, names(2))
+
+	k.OnDelete(toolscache.DeletedFinalStateUnknown{Key: "default/web", Obj: newPod("web")})
+	renamed := newPod("api-web")
+	renamed.Labels = map[string]string{"app": "web"}
+	k.OnUpdate(newPod("api-web"), renamed)
+	assert.Equal(t, []string{"web-canary", "api-web"}, names(0))
+	assert.Equal(t, "label app=web", k.search("pods", parseSearchQuery("app=web"), 0)[0].Match)
+}
@@ -278,44 +278,6 @@ func (h *StorageClassHandler) registerCustomRoutes(group *gin.RouterGroup) {
 	group.GET("/_all/:name/pvcs", h.GetRelatedPVCs)
 }
 
-// Search implementation for StorageClass
-func (h *StorageClassHandler) Search(c *gin.Context, q string, limit int64) ([]common.SearchResult, error) {
-	if len(q) < 3 {
-		return nil, nil
-	}
-
-	cs := c.MustGet("cluster").(*cluster.ClientSet)
-	ctx := c.Request.Context()
-
-	var scList storagev1.StorageClassList
-	if err := cs.K8sClient.List(ctx, &scList); err != nil {
-		klog.Errorf("failed to list storageclasses: %v", err)
-		return nil, err
-	}
-
-	results := make([]common.SearchResult, 0, limit)
-
-	for _, sc := range scList.Items {
-		if !strings.Contains(strings.ToLower(sc.Name), strings.ToLower(q)) {
-			continue
-		}
-
-		result := common.SearchResult{
-			ID:           string(sc.GetUID()),
-			Name:         sc.GetName(),
-			Namespace:    sc.GetNamespace(),
-			ResourceType: "storageclasses",
-			CreatedAt:    sc.GetCreationTimestamp().String(),
-		}
-		results = append(results, result)
-		if limit > 0 && int64(len(results)) >= limit {
-			break
-		}
-	}
-
-	return results, nil
-}
-
 // GetResource returns a StorageClass resource
 func (h *StorageClassHandler) GetResource(c *gin.Context, namespace, name string) (interface{}, error) {
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
@@ -2,7 +2,6 @@ package resources
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/zxh326/kite/pkg/audit"
 	"github.com/zxh326/kite/pkg/cluster"
 	"github.com/zxh326/kite/pkg/common"
 	"github.com/zxh326/kite/pkg/kube"
@@ -179,6 +179,7 @@ func (h *TailscaleResourceHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, nil, &cr)
 
 	c.JSON(http.StatusCreated, cr)
 }
@@ -246,6 +247,7 @@ func (h *TailscaleResourceHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, existingCR, &updatedCR)
 
 	c.JSON(http.StatusOK, updatedCR)
 }
@@ -303,6 +305,7 @@ func (h *TailscaleResourceHandler) Delete(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.RecordChange(c, cr, nil)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Custom resource deleted successfully"})
 }
@@ -319,7 +322,12 @@ func (h *TailscaleResourceHandler) Searchable() bool {
 
 // Search implements search functionality for custom resources
 func (h *TailscaleResourceHandler) Search(c *gin.Context, query string, limit int64) ([]common.SearchResult, error) {
-	return nil, fmt.Errorf("search not implemented for Tailscale resources")
+	gvk := schema.GroupVersionKind{Group: h.group, Version: h.version, Kind: h.kind}
+	return searchUnstructured(c, gvk, h.resourceName, query, limit)
+}
+
+func (h *TailscaleResourceHandler) groupKind() schema.GroupKind {
+	return schema.GroupKind{Group: h.group, Kind: h.kind}
 }
 
 // GetResource retrieves a specific custom resource
@@ -0,0 +1,394 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/zxh326/kite/pkg/cluster"
+)
+
+// watchHeartbeatInterval keeps idle streams alive through proxies
+const watchHeartbeatInterval = 30 * time.Second
+
+// WatchEventSynced is sent once the current objects were streamed
+const WatchEventSynced watch.EventType = "SYNCED"
+
+// WatchEvent is streamed as a server-sent event named after its type, with the
+// resourceVersion as event id so EventSource reconnects resume automatically
+type WatchEvent struct {
+	Type   watch.EventType `json:"type"`
+	Object any             `json:"object,omitempty"`
+	// Keys lists the namespace/name of every current object in the SYNCED
+	// event of a resumed watch, so clients can drop objects deleted meanwhile
+	Keys []string `json:"keys,omitempty"`
+	// Error is set for ERROR events, a 410 code means the resourceVersion is
+	// too old and the client has to start over without one
+	Error string `json:"error,omitempty"`
+	Code  int32  `json:"code,omitempty"`
+
+	resourceVersion string
+}
+
+// watchableHandler is implemented by handlers of typed resources
+type watchableHandler interface {
+	newObject() client.Object
+	newList() client.ObjectList
+}
+
+func (h *GenericResourceHandler[T, V]) newObject() client.Object {
+	return reflect.New(h.objectType).Interface().(T)
+}
+
+func (h *GenericResourceHandler[T, V]) newList() client.ObjectList {
+	return reflect.New(h.listType).Interface().(V)
+}
+
+// watchFilter selects the objects of a watch, like the List parameters
+type watchFilter struct {
+	namespace string
+	labels    labels.Selector
+	fields    fields.Selector
+}
+
+func newWatchFilter(c *gin.Context, clusterScoped bool) (*watchFilter, error) {
+	filter := &watchFilter{labels: labels.Everything(), fields: fields.Everything()}
+	if namespace := c.Param("namespace"); !clusterScoped && namespace != "_all" {
+		filter.namespace = namespace
+	}
+	if labelSelector := c.Query("labelSelector"); labelSelector != "" {
+		selector, err := labels.Parse(labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labelSelector parameter: %w", err)
+		}
+		filter.labels = selector
+	}
+	if fieldSelector := c.Query("fieldSelector"); fieldSelector != "" {
+		selector, err := fields.ParseSelector(fieldSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fieldSelector parameter: %w", err)
+		}
+		filter.fields = selector
+	}
+	return filter, nil
+}
+
+func (f *watchFilter) listOptions() *client.ListOptions {
+	return &client.ListOptions{
+		Namespace:     f.namespace,
+		LabelSelector: f.labels,
+		FieldSelector: f.fields,
+	}
+}
+
+// matches evaluates the filter locally, field selectors are dotted paths
+// into the object like metadata.name or spec.nodeName
+func (f *watchFilter) matches(obj client.Object) bool {
+	if f.namespace != "" && obj.GetNamespace() != f.namespace {
+		return false
+	}
+	if !f.labels.Matches(labels.Set(obj.GetLabels())) {
+		return false
+	}
+	if f.fields.Empty() {
+		return true
+	}
+
+	var content map[string]any
+	set := fields.Set{}
+	for _, requirement := range f.fields.Requirements() {
+		switch requirement.Field {
+		case "metadata.name":
+			set[requirement.Field] = obj.GetName()
+		case "metadata.namespace":
+			set[requirement.Field] = obj.GetNamespace()
+		default:
+			if content == nil {
+				var err error
+				if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+					return false
+				}
+			}
+			if value, found, _ := unstructured.NestedFieldNoCopy(content, strings.Split(requirement.Field, ".")...); found {
+				set[requirement.Field] = fmt.Sprint(value)
+			}
+		}
+	}
+	return f.fields.Matches(set)
+}
+
+// newerThan reports whether resourceVersion is after since. Resource versions
+// are opaque, objects are considered new when either isn't a number.
+func newerThan(resourceVersion, since string) bool {
+	rv, err1 := strconv.ParseUint(resourceVersion, 10, 64)
+	sinceRV, err2 := strconv.ParseUint(since, 10, 64)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	return rv > sinceRV
+}
+
+func objectKey(obj client.Object) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// WatchResource streams changes of a resource as server-sent events. Objects
+// come from the informer cache, kinds that aren't cached are watched on the
+// API server. Pass resourceVersion, or reconnect with Last-Event-ID, to only
+// receive objects changed since then.
+func WatchResource(c *gin.Context) {
+	resource := c.Param("resource")
+	handler, ok := handlers[resource].(watchableHandler)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("resource %s does not support watch", resource)})
+		return
+	}
+	filter, err := newWatchFilter(c, handlers[resource].IsClusterScoped())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	since := c.Query("resourceVersion")
+	if since == "" {
+		since = c.GetHeader("Last-Event-ID")
+	}
+
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	// Stopping the stream also releases the informer handler and the
+	// forwarders, which all wait on the request context
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	events := make(chan WatchEvent, 64)
+	informer, cached, err := cs.K8sClient.Informer(ctx, handler.newObject())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if cached {
+		registration, err := watchInformer(c, informer, filter, since, events)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer func() {
+			_ = informer.RemoveEventHandler(registration)
+		}()
+	} else {
+		opts := filter.listOptions()
+		opts.Raw = &metav1.ListOptions{ResourceVersion: since}
+		watcher, err := cs.K8sClient.Watch(ctx, handler.newList(), opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer watcher.Stop()
+		go forwardWatch(c, watcher, events)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+	lastID := since
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cs.K8sClient.Done():
+			// The cluster was reloaded or removed, clients have to reconnect
+			event := WatchEvent{Type: watch.Error, Error: "cluster cache stopped, reconnect to resume", Code: http.StatusServiceUnavailable}
+			if err := writeWatchEvent(c, event, ""); err != nil {
+				klog.V(2).Infof("Failed to write watch event: %v", err)
+			}
+			return
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			// Deleted objects carry their last known version, the event id only
+			// moves forward so reconnects never replay from an older point
+			id := ""
+			if event.resourceVersion != "" && newerThan(event.resourceVersion, lastID) {
+				id, lastID = event.resourceVersion, event.resourceVersion
+			}
+			if err := writeWatchEvent(c, event, id); err != nil {
+				klog.V(2).Infof("Failed to write watch event: %v", err)
+				return
+			}
+			if event.Type == watch.Error {
+				return
+			}
+		}
+	}
+}
+
+func writeWatchEvent(c *gin.Context, event WatchEvent, id string) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	b.WriteString("event: " + string(event.Type) + "\n")
+	b.WriteString("data: " + string(data) + "\n\n")
+	if _, err := c.Writer.WriteString(b.String()); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// watchInformer turns informer notifications into watch events. Updates that
+// move an object in or out of the filter become ADDED or DELETED events.
+// Objects of the initial list arrive in no particular order, so they carry no
+// resourceVersion and the SYNCED event carries the highest one instead.
+func watchInformer(c *gin.Context, informer ctrlcache.Informer, filter *watchFilter, since string, events chan<- WatchEvent) (toolscache.ResourceEventHandlerRegistration, error) {
+	ctx := c.Request.Context()
+	send := func(eventType watch.EventType, obj client.Object, initial bool) {
+		event := WatchEvent{Type: eventType, Object: obj}
+		if !initial {
+			event.resourceVersion = obj.GetResourceVersion()
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	var (
+		mu          sync.Mutex
+		initialKeys []string
+		initialRV   = since
+	)
+	registration, err := informer.AddEventHandler(toolscache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(raw any, isInInitialList bool) {
+			obj, ok := raw.(client.Object)
+			if !ok || !filter.matches(obj) {
+				return
+			}
+			if !isInInitialList {
+				send(watch.Added, obj, false)
+				return
+			}
+			mu.Lock()
+			if initialRV == "" || newerThan(obj.GetResourceVersion(), initialRV) {
+				initialRV = obj.GetResourceVersion()
+			}
+			if since != "" {
+				initialKeys = append(initialKeys, objectKey(obj))
+			}
+			mu.Unlock()
+			if since == "" {
+				send(watch.Added, obj, true)
+			} else if newerThan(obj.GetResourceVersion(), since) {
+				send(watch.Modified, obj, true)
+			}
+		},
+		UpdateFunc: func(oldRaw, newRaw any) {
+			oldObj, ok1 := oldRaw.(client.Object)
+			newObj, ok2 := newRaw.(client.Object)
+			if !ok1 || !ok2 || oldObj.GetResourceVersion() == newObj.GetResourceVersion() {
+				// Periodic resyncs don't change anything
+				return
+			}
+			oldMatch, newMatch := filter.matches(oldObj), filter.matches(newObj)
+			switch {
+			case oldMatch && newMatch:
+				send(watch.Modified, newObj, false)
+			case newMatch:
+				send(watch.Added, newObj, false)
+			case oldMatch:
+				send(watch.Deleted, newObj, false)
+			}
+		},
+		DeleteFunc: func(raw any) {
+			if tombstone, ok := raw.(toolscache.DeletedFinalStateUnknown); ok {
+				raw = tombstone.Obj
+			}
+			if obj, ok := raw.(client.Object); ok && filter.matches(obj) {
+				send(watch.Deleted, obj, false)
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if !toolscache.WaitForCacheSync(ctx.Done(), registration.HasSynced) {
+			return
+		}
+		mu.Lock()
+		event := WatchEvent{Type: WatchEventSynced, Keys: initialKeys, resourceVersion: initialRV}
+		mu.Unlock()
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}()
+	return registration, nil
+}
+
+// forwardWatch relays an API server watch to the event stream
+func forwardWatch(c *gin.Context, watcher watch.Interface, events chan<- WatchEvent) {
+	defer close(events)
+	ctx := c.Request.Context()
+	for raw := range watcher.ResultChan() {
+		var event WatchEvent
+		switch raw.Type {
+		case watch.Error:
+			status := apierrors.FromObject(raw.Object)
+			event = WatchEvent{Type: watch.Error, Error: status.Error()}
+			if statusErr, ok := status.(apierrors.APIStatus); ok {
+				event.Code = statusErr.Status().Code
+			}
+		case watch.Bookmark:
+			continue
+		default:
+			obj, ok := raw.Object.(client.Object)
+			if !ok {
+				continue
+			}
+			event = WatchEvent{Type: raw.Type, Object: obj, resourceVersion: obj.GetResourceVersion()}
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package resources
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestWatchFilter(t *testing.T, target string, namespace string) *watchFilter {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil)
+	if namespace != "" {
+		c.Params = gin.Params{{Key: "namespace", Value: namespace}}
+	}
+	filter, err := newWatchFilter(c, false)
+	require.NoError(t, err)
+	return filter
+}
+
+func TestWatchFilterMatches(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{NodeName: "node-a"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	tests := []struct {
+		name      string
+		target    string
+		namespace string
+		want      bool
+	}{
+		{"everything", "/watch/pods", "", true},
+		{"all namespaces", "/watch/pods/_all", "_all", true},
+		{"namespace", "/watch/pods/default", "default", true},
+		{"other namespace", "/watch/pods/kube-system", "kube-system", false},
+		{"label", "/watch/pods?labelSelector=app%3Dweb", "", true},
+		{"label mismatch", "/watch/pods?labelSelector=app%3Ddb", "", false},
+		{"node name", "/watch/pods?fieldSelector=spec.nodeName%3Dnode-a", "", true},
+		{"node name mismatch", "/watch/pods?fieldSelector=spec.nodeName%3Dnode-b", "", false},
+		{"name and phase", "/watch/pods?fieldSelector=metadata.name%3Dweb-1,status.phase%21%3DPending", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newTestWatchFilter(t, tt.target, tt.namespace).matches(pod))
+		})
+	}
+}
+
+func TestWatchFilterInvalidSelector(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/watch/pods?labelSelector=app%3D%3D%3D", nil)
+	_, err := newWatchFilter(c, false)
+	assert.Error(t, err)
+}
+
+func TestNewerThan(t *testing.T) {
+	assert.True(t, newerThan("12", "9"))
+	assert.False(t, newerThan("9", "12"))
+	assert.False(t, newerThan("12", "12"))
+	assert.True(t, newerThan("12", ""))
+	assert.True(t, newerThan("opaque", "12"))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/zxh326/kite/pkg/cluster"
 	"github.com/zxh326/kite/pkg/kube"
@@ -80,7 +81,7 @@ func TestWorkloadCache_GetCachedWorkloadStatus(t *testing.T) {
 
 			mockK8sClient := &MockK8sClient{}
 			cs := &cluster.ClientSet{
-				K8sClient: &kube.K8sClient{},
+				K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().Build()},
 			}
 
 			// Mock CRD existence check
@@ -150,7 +151,7 @@ func TestWorkloadCache_BatchOptimization(t *testing.T) {
 
 	mockK8sClient := &MockK8sClient{}
 	cs := &cluster.ClientSet{
-		K8sClient: &kube.K8sClient{},
+		K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().Build()},
 	}
 
 	// Mock CRD existence check
@@ -179,7 +180,7 @@ func BenchmarkWorkloadCache_WithCache(b *testing.B) {
 	}
 
 	cs := &cluster.ClientSet{
-		K8sClient: &kube.K8sClient{},
+		K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().Build()},
 	}
 	ctx := context.Background()
 
@@ -201,7 +202,7 @@ func BenchmarkWorkloadCache_WithoutCache(b *testing.B) {
 	}
 
 	cs := &cluster.ClientSet{
-		K8sClient: &kube.K8sClient{},
+		K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().Build()},
 	}
 	ctx := context.Background()
 
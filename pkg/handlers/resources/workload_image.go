@@ -0,0 +1,288 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/zxh326/kite/pkg/cluster"
+)
+
+// ErrContainerNotFound is returned when a workload has no container of the
+// requested name
+var ErrContainerNotFound = errors.New("container not found")
+
+// ErrRolloutFailed is returned when a workload reports its rollout can't
+// complete, as opposed to not having completed yet
+var ErrRolloutFailed = errors.New("rollout failed")
+
+// ImageUpdate is the outcome of UpdateImage
+type ImageUpdate struct {
+	Container     string `json:"container"`
+	PreviousImage string `json:"previousImage"`
+	Image         string `json:"image"`
+	// Changed is false when the container already ran the image
+	Changed bool `json:"changed"`
+	// Generation is the generation of the workload after the update, its
+	// rollout completes once the controller observed it
+	Generation int64 `json:"-"`
+}
+
+// newWorkload returns an empty object of a workload resource with pods
+// updated through its pod template
+func newWorkload(resource string) (client.Object, error) {
+	switch resource {
+	case "deployments":
+		return &appsv1.Deployment{}, nil
+	case "statefulsets":
+		return &appsv1.StatefulSet{}, nil
+	case "daemonsets":
+		return &appsv1.DaemonSet{}, nil
+	case "clonesets":
+		return &kruiseappsv1alpha1.CloneSet{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported resource type: %s", resource)
+	}
+}
+
+func podTemplateOf(obj client.Object) *corev1.PodTemplateSpec {
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		return &workload.Spec.Template
+	case *appsv1.StatefulSet:
+		return &workload.Spec.Template
+	case *appsv1.DaemonSet:
+		return &workload.Spec.Template
+	case *kruiseappsv1alpha1.CloneSet:
+		return &workload.Spec.Template
+	}
+	return nil
+}
+
+// ParseContainerImage splits the containerName:image form used by webhooks,
+// the image itself may contain colons for its tag and registry port
+func ParseContainerImage(data string) (container, image string, err error) {
+	container, image, ok := strings.Cut(data, ":")
+	if !ok || container == "" || image == "" {
+		return "", "", fmt.Errorf("invalid data %q, expected containerName:image", data)
+	}
+	return container, image, nil
+}
+
+// UpdateImage sets the image of a container, or init container, of a
+// deployment, statefulset, daemonset or cloneset
+func UpdateImage(ctx context.Context, cs *cluster.ClientSet, resource, namespace, name, container, image string) (*ImageUpdate, error) {
+	result := &ImageUpdate{Container: container, Image: image}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := newWorkload(resource)
+		if err != nil {
+			return err
+		}
+		if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+			return err
+		}
+
+		spec := &podTemplateOf(obj).Spec
+		target := findPodContainer(spec, container)
+		if target == nil {
+			names := make([]string, 0, len(spec.Containers)+len(spec.InitContainers))
+			for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+				names = append(names, c.Name)
+			}
+			return fmt.Errorf("%w: %s in %s %s/%s, available containers: %s", ErrContainerNotFound, container, resource, namespace, name, strings.Join(names, ", "))
+		}
+		result.PreviousImage = target.Image
+		result.Changed = target.Image != image
+		if !result.Changed {
+			result.Generation = obj.GetGeneration()
+			return nil
+		}
+		target.Image = image
+		if err := cs.K8sClient.Update(ctx, obj); err != nil {
+			return err
+		}
+		result.Generation = obj.GetGeneration()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WorkloadContainer is a container, or init container, of the pod template
+// of a workload
+type WorkloadContainer struct {
+	Resource    string
+	Namespace   string
+	Name        string
+	Container   string
+	Image       string
+	PullPolicy  corev1.PullPolicy
+	Annotations map[string]string
+}
+
+// ListWorkloadContainers lists the containers of the deployments,
+// statefulsets, daemonsets and clonesets of a namespace, of all namespaces
+// when it is empty. Clonesets are skipped when OpenKruise isn't installed.
+func ListWorkloadContainers(ctx context.Context, cs *cluster.ClientSet, namespace string) ([]WorkloadContainer, error) {
+	lists := []struct {
+		resource string
+		list     client.ObjectList
+	}{
+		{"deployments", &appsv1.DeploymentList{}},
+		{"statefulsets", &appsv1.StatefulSetList{}},
+		{"daemonsets", &appsv1.DaemonSetList{}},
+		{"clonesets", &kruiseappsv1alpha1.CloneSetList{}},
+	}
+	var result []WorkloadContainer
+	for _, l := range lists {
+		resource, list := l.resource, l.list
+		if err := cs.K8sClient.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			if resource == "clonesets" && meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", resource, err)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			obj := item.(client.Object)
+			spec := &podTemplateOf(obj).Spec
+			for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+				result = append(result, WorkloadContainer{
+					Resource:    resource,
+					Namespace:   obj.GetNamespace(),
+					Name:        obj.GetName(),
+					Container:   c.Name,
+					Image:       c.Image,
+					PullPolicy:  c.ImagePullPolicy,
+					Annotations: obj.GetAnnotations(),
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+func findPodContainer(spec *corev1.PodSpec, name string) *corev1.Container {
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == name {
+			return &spec.Containers[i]
+		}
+	}
+	for i := range spec.InitContainers {
+		if spec.InitContainers[i].Name == name {
+			return &spec.InitContainers[i]
+		}
+	}
+	return nil
+}
+
+// rolloutComplete reports whether all pods of a workload run its current
+// pod template and are available. It fails for deployments that exceeded
+// their progress deadline.
+func rolloutComplete(obj client.Object) (bool, error) {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		if w.Status.ObservedGeneration < w.Generation {
+			return false, nil
+		}
+		for _, cond := range w.Status.Conditions {
+			if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+				return false, fmt.Errorf("%w: deployment %s exceeded its progress deadline", ErrRolloutFailed, w.Name)
+			}
+		}
+		replicas := int32(1)
+		if w.Spec.Replicas != nil {
+			replicas = *w.Spec.Replicas
+		}
+		return w.Status.UpdatedReplicas == replicas &&
+			w.Status.Replicas == replicas &&
+			w.Status.AvailableReplicas == replicas, nil
+	case *appsv1.StatefulSet:
+		if w.Status.ObservedGeneration < w.Generation {
+			return false, nil
+		}
+		if w.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			// Pods are only replaced when deleted, there is nothing to wait for
+			return true, nil
+		}
+		replicas := int32(1)
+		if w.Spec.Replicas != nil {
+			replicas = *w.Spec.Replicas
+		}
+		updated := replicas
+		if ru := w.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+			updated = max(replicas-*ru.Partition, 0)
+		}
+		return w.Status.UpdatedReplicas >= updated && w.Status.ReadyReplicas == replicas, nil
+	case *appsv1.DaemonSet:
+		if w.Status.ObservedGeneration < w.Generation {
+			return false, nil
+		}
+		return w.Status.UpdatedNumberScheduled == w.Status.DesiredNumberScheduled &&
+			w.Status.NumberAvailable == w.Status.DesiredNumberScheduled, nil
+	case *kruiseappsv1alpha1.CloneSet:
+		if w.Status.ObservedGeneration < w.Generation {
+			return false, nil
+		}
+		replicas := int32(1)
+		if w.Spec.Replicas != nil {
+			replicas = *w.Spec.Replicas
+		}
+		updated := replicas
+		if w.Spec.UpdateStrategy.Partition != nil {
+			partition, err := intstr.GetScaledValueFromIntOrPercent(w.Spec.UpdateStrategy.Partition, int(replicas), true)
+			if err != nil {
+				return false, err
+			}
+			updated = max(replicas-int32(partition), 0)
+		}
+		return w.Status.UpdatedReadyReplicas >= updated && w.Status.ReadyReplicas == replicas, nil
+	}
+	return false, fmt.Errorf("unsupported workload %T", obj)
+}
+
+// WaitForRollout polls a workload until the rollout of generation, or a
+// later one, completes or the context is done. Workloads are read from the
+// cache, which may still hold the rolled out object from before an update.
+func WaitForRollout(ctx context.Context, cs *cluster.ClientSet, resource, namespace, name string, generation int64) error {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		obj, err := newWorkload(resource)
+		if err != nil {
+			return err
+		}
+		if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+			return err
+		}
+		// rolloutComplete requires the controller to have observed the
+		// generation of the object it is given
+		if obj.GetGeneration() >= generation {
+			done, err := rolloutComplete(obj)
+			if err != nil || done {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("rollout of %s %s/%s did not complete: %w", resource, namespace, name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
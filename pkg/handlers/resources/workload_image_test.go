@@ -0,0 +1,154 @@
+package resources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/kube"
+)
+
+func TestParseContainerImage(t *testing.T) {
+	container, image, err := ParseContainerImage("app:registry.local:5000/team/app:v2")
+	require.NoError(t, err)
+	assert.Equal(t, "app", container)
+	assert.Equal(t, "registry.local:5000/team/app:v2", image)
+
+	for _, data := range []string{"app", ":nginx", "app:"} {
+		_, _, err := ParseContainerImage(data)
+		assert.Error(t, err, data)
+	}
+}
+
+func TestUpdateImage(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "migrate", Image: "web:v1"}},
+			Containers:     []corev1.Container{{Name: "web", Image: "web:v1"}, {Name: "proxy", Image: "envoy:1.30"}},
+		}}},
+	}
+	cs := &cluster.ClientSet{K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().WithObjects(deployment).Build()}}
+	ctx := context.Background()
+
+	update, err := UpdateImage(ctx, cs, "deployments", "default", "web", "web", "web:v2")
+	require.NoError(t, err)
+	assert.Equal(t, &ImageUpdate{Container: "web", PreviousImage: "web:v1", Image: "web:v2", Changed: true}, update)
+
+	var got appsv1.Deployment
+	require.NoError(t, cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "web"}, &got))
+	assert.Equal(t, "web:v2", got.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "envoy:1.30", got.Spec.Template.Spec.Containers[1].Image)
+	assert.Equal(t, "web:v1", got.Spec.Template.Spec.InitContainers[0].Image)
+
+	update, err = UpdateImage(ctx, cs, "deployments", "default", "web", "migrate", "web:v2")
+	require.NoError(t, err)
+	assert.True(t, update.Changed)
+
+	update, err = UpdateImage(ctx, cs, "deployments", "default", "web", "web", "web:v2")
+	require.NoError(t, err)
+	assert.False(t, update.Changed)
+
+	_, err = UpdateImage(ctx, cs, "deployments", "default", "web", "sidecar", "web:v2")
+	assert.ErrorIs(t, err, ErrContainerNotFound)
+	_, err = UpdateImage(ctx, cs, "jobs", "default", "web", "web", "web:v2")
+	assert.Error(t, err)
+}
+
+func TestRolloutComplete(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](3)},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 2, AvailableReplicas: 3},
+	}
+	done, err := rolloutComplete(deployment)
+	require.NoError(t, err)
+	assert.False(t, done)
+
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}
+	done, err = rolloutComplete(deployment)
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	deployment.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"}}
+	_, err = rolloutComplete(deployment)
+	assert.ErrorIs(t, err, ErrRolloutFailed)
+
+	// A partition of 40% of 5 keeps 2 pods on the old revision
+	partition := intstr.FromString("40%")
+	cloneSet := &kruiseappsv1alpha1.CloneSet{
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Replicas:       ptr.To[int32](5),
+			UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{Partition: &partition},
+		},
+		Status: kruiseappsv1alpha1.CloneSetStatus{ReadyReplicas: 5, UpdatedReadyReplicas: 3},
+	}
+	done, err = rolloutComplete(cloneSet)
+	require.NoError(t, err)
+	assert.True(t, done)
+}
+
+func TestListWorkloadContainers(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kruiseappsv1alpha1.AddToScheme(scheme))
+	podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "team/app:v1"}}}
+	objects := []client.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps", Annotations: map[string]string{"a": "b"}},
+			Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: podSpec}},
+		},
+		&kruiseappsv1alpha1.CloneSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "jobs"},
+			Spec:       kruiseappsv1alpha1.CloneSetSpec{Template: corev1.PodTemplateSpec{Spec: podSpec}},
+		},
+	}
+	cs := &cluster.ClientSet{K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()}}
+
+	containers, err := ListWorkloadContainers(context.Background(), cs, "")
+	require.NoError(t, err)
+	assert.Equal(t, []WorkloadContainer{
+		{Resource: "deployments", Namespace: "apps", Name: "web", Container: "app", Image: "team/app:v1", Annotations: map[string]string{"a": "b"}},
+		{Resource: "clonesets", Namespace: "jobs", Name: "worker", Container: "app", Image: "team/app:v1"},
+	}, containers)
+
+	containers, err = ListWorkloadContainers(context.Background(), cs, "jobs")
+	require.NoError(t, err)
+	assert.Len(t, containers, 1)
+}
+
+func TestWaitForRolloutStaleCache(t *testing.T) {
+	rolledOut := func(generation int64) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](1)},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: generation, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+		}
+	}
+	wait := func(cached *appsv1.Deployment, generation int64) error {
+		cs := &cluster.ClientSet{K8sClient: &kube.K8sClient{Client: fake.NewClientBuilder().WithObjects(cached).Build()}}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return WaitForRollout(ctx, cs, "deployments", "default", "web", generation)
+	}
+
+	// The cache still holds the object from before the update to generation 2
+	assert.ErrorIs(t, wait(rolledOut(1), 2), context.DeadlineExceeded)
+	assert.NoError(t, wait(rolledOut(2), 2))
+	assert.NoError(t, wait(rolledOut(3), 2))
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,7 +14,8 @@ import (
 
 	"github.com/zxh326/kite/pkg/common"
 	"github.com/zxh326/kite/pkg/handlers/resources"
-	"github.com/zxh326/kite/pkg/utils"
+	"github.com/zxh326/kite/pkg/middleware"
+	"github.com/zxh326/kite/pkg/rbac"
 )
 
 type SearchHandler struct {
@@ -29,36 +32,81 @@ func NewSearchHandler() *SearchHandler {
 	}
 }
 
-func (h *SearchHandler) createCacheKey(query string) string {
-	return fmt.Sprintf("search:%s", query)
+// createCacheKey scopes cached results to the cluster, and to the user when
+// impersonating or with role checks since results then depend on their
+// permissions
+func (h *SearchHandler) createCacheKey(c *gin.Context, query string, limit int) string {
+	identity := ""
+	if common.EnableImpersonation || rbac.Enabled() {
+		if user, ok := c.Get("user"); ok {
+			subject := rbac.SubjectFromUser(user.(gin.H))
+			identity = subject.Username + "|" + strings.Join(subject.Groups, ",")
+		}
+	}
+	return fmt.Sprintf("search:%s:%s:%d:%s", c.GetString(middleware.ClusterNameKey), identity, limit, query)
 }
 
 func (h *SearchHandler) Search(c *gin.Context, query string, limit int) ([]common.SearchResult, error) {
+	allResults := searchCluster(c, query, limit)
+	h.cache.Add(h.createCacheKey(c, query, limit), allResults)
+	return allResults, nil
+}
+
+// searchCluster searches the cluster of the context, results are sorted and limited
+func searchCluster(c *gin.Context, query string, limit int) []common.SearchResult {
 	var allResults []common.SearchResult
 
 	// Search in different resource types
-	searchFuncs := resources.SearchFuncs
-	guessSearchResources, q := utils.GuessSearchResources(query)
-	for name, searchFunc := range searchFuncs {
-		if guessSearchResources == "all" || name == guessSearchResources {
-			results, err := searchFunc(c, q, int64(limit))
-			if err != nil {
-				continue
-			}
-			allResults = append(allResults, results...)
+	searchFuncs, q := resources.ResolveSearch(c, query)
+	for _, searchFunc := range searchFuncs {
+		results, err := searchFunc(c, q, int64(limit))
+		if err != nil {
+			continue
 		}
+		allResults = append(allResults, results...)
 	}
 
-	queryLower := strings.ToLower(q)
-	sortResults(allResults, queryLower)
+	allResults = authorizedResults(c, allResults)
+	sortResults(allResults)
 
 	// Limit total results
 	if len(allResults) > limit {
 		allResults = allResults[:limit]
 	}
+	return allResults
+}
 
-	h.cache.Add(h.createCacheKey(query), allResults)
-	return allResults, nil
+// authorizedResults drops the results the user may not view. The search
+// route is open to namespace scoped users, so results of other namespaces
+// and cluster-scoped results are filtered here.
+func authorizedResults(c *gin.Context, results []common.SearchResult) []common.SearchResult {
+	if !rbac.Enabled() {
+		return results
+	}
+	user, ok := c.Get("user")
+	if !ok {
+		return nil
+	}
+	subject := rbac.SubjectFromUser(user.(gin.H))
+	clusterName := c.GetString(middleware.ClusterNameKey)
+	return filterSearchResults(results, func(namespace string) bool {
+		return rbac.Authorize(subject, rbac.Request{Cluster: clusterName, Namespace: namespace, Role: rbac.RoleViewer})
+	})
+}
+
+func filterSearchResults(results []common.SearchResult, canView func(namespace string) bool) []common.SearchResult {
+	filtered := make([]common.SearchResult, 0, len(results))
+	for _, result := range results {
+		namespace := result.Namespace
+		if result.ResourceType == "namespaces" {
+			// Users bound to a namespace may find the namespace itself
+			namespace = result.Name
+		}
+		if canView(namespace) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
 }
 
 // GlobalSearch handles global search across multiple resource types
@@ -76,16 +124,21 @@ func (h *SearchHandler) GlobalSearch(c *gin.Context) {
 		limit = 50
 	}
 
-	cacheKey := h.createCacheKey(query)
+	cacheKey := h.createCacheKey(c, query, limit)
 
 	if cachedResults, found := h.cache.Get(cacheKey); found {
 		response := SearchResponse{
 			Results: cachedResults,
 			Total:   len(cachedResults),
 		}
+		// Perform search in the background to update cache, on a copy of the
+		// context since gin reuses it once the request is done
+		cc := c.Copy()
 		go func() {
-			// Perform search in the background to update cache
-			_, _ = h.Search(c, query, limit)
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(cc.Request.Context()), time.Minute)
+			defer cancel()
+			cc.Request = cc.Request.WithContext(ctx)
+			_, _ = h.Search(cc, query, limit)
 		}()
 		c.JSON(http.StatusOK, response)
 		return
@@ -123,40 +176,15 @@ func getResourceOrder(resourceType string) int {
 	return len(resourceOrder) // Default to the end if not found
 }
 
-// sortResults sorts the search results with exact matches first, then by resource type
-func sortResults(results []common.SearchResult, query string) {
-	var exactMatches, partialMatches []common.SearchResult
-
-	for _, result := range results {
-		if strings.ToLower(result.Name) == query {
-			exactMatches = append(exactMatches, result)
-		} else {
-			partialMatches = append(partialMatches, result)
+// sortResults ranks results by score, ties are ordered by resource type and name
+func sortResults(results []common.SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
 		}
-	}
-
-	// sort by resources
-	sortByResources := func(a, b common.SearchResult) bool {
-		return getResourceOrder(a.ResourceType) < getResourceOrder(b.ResourceType)
-	}
-
-	// Simple bubble sort for demonstration
-	for i := 0; i < len(exactMatches)-1; i++ {
-		for j := 0; j < len(exactMatches)-i-1; j++ {
-			if !sortByResources(exactMatches[j], exactMatches[j+1]) {
-				exactMatches[j], exactMatches[j+1] = exactMatches[j+1], exactMatches[j]
-			}
-		}
-	}
-
-	for i := 0; i < len(partialMatches)-1; i++ {
-		for j := 0; j < len(partialMatches)-i-1; j++ {
-			if !sortByResources(partialMatches[j], partialMatches[j+1]) {
-				partialMatches[j], partialMatches[j+1] = partialMatches[j+1], partialMatches[j]
-			}
+		if order1, order2 := getResourceOrder(results[i].ResourceType), getResourceOrder(results[j].ResourceType); order1 != order2 {
+			return order1 < order2
 		}
-	}
-
-	// Combine results
-	copy(results, append(exactMatches, partialMatches...))
+		return results[i].Name < results[j].Name
+	})
 }
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/middleware"
+	"github.com/zxh326/kite/pkg/rbac"
+)
+
+func TestSearchCacheKeyIncludesCluster(t *testing.T) {
+	h := NewSearchHandler()
+	key := func(clusterName string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set(middleware.ClusterNameKey, clusterName)
+		return h.createCacheKey(c, "nginx", 50)
+	}
+	assert.NotEqual(t, key("prod"), key("staging"))
+	assert.Equal(t, key("prod"), key("prod"))
+}
+
+func TestFilterSearchResults(t *testing.T) {
+	config, err := rbac.ParseConfig([]byte(`
+bindings:
+  - role: viewer
+    users: [alice]
+    namespaces: [team-a]
+`))
+	require.NoError(t, err)
+	alice := rbac.Subject{Username: "alice"}
+	results := []common.SearchResult{
+		{Name: "web", Namespace: "team-a", ResourceType: "pods"},
+		{Name: "db-password", Namespace: "team-b", ResourceType: "secrets"},
+		{Name: "node-1", ResourceType: "nodes"},
+		{Name: "team-a", ResourceType: "namespaces"},
+		{Name: "team-b", ResourceType: "namespaces"},
+	}
+
+	filtered := filterSearchResults(results, func(namespace string) bool {
+		return config.Allowed(alice, rbac.Request{Cluster: "prod", Namespace: namespace, Role: rbac.RoleViewer})
+	})
+	assert.Equal(t, []common.SearchResult{results[0], results[3]}, filtered)
+}
@@ -2,14 +2,17 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/net/websocket"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
 	"github.com/zxh326/kite/pkg/cluster"
 	"github.com/zxh326/kite/pkg/kube"
+	"github.com/zxh326/kite/pkg/recording"
 )
 
 type TerminalHandler struct {
@@ -19,7 +22,11 @@ func NewTerminalHandler() *TerminalHandler {
 	return &TerminalHandler{}
 }
 
-// HandleTerminalWebSocket handles WebSocket connections for terminal sessions
+// HandleTerminalWebSocket handles WebSocket connections for terminal sessions.
+// With debug=ephemeral the terminal is attached to a new ephemeral container
+// sharing the process namespace of the container, with debug=copy to a copy
+// of the pod running command in place of the container. image overrides the
+// debug image, command may be repeated for arguments.
 func (h *TerminalHandler) HandleTerminalWebSocket(c *gin.Context) {
 	// Get cluster info from context
 	cs := c.MustGet("cluster").(*cluster.ClientSet)
@@ -34,14 +41,61 @@ func (h *TerminalHandler) HandleTerminalWebSocket(c *gin.Context) {
 		return
 	}
 
+	debug := debugOptions{
+		mode:    c.Query("debug"),
+		image:   c.Query("image"),
+		target:  container,
+		command: c.QueryArray("command"),
+	}
+	switch debug.mode {
+	case "", debugModeEphemeral, debugModeCopy:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "debug must be ephemeral or copy"})
+		return
+	}
+
 	websocket.Handler(func(ws *websocket.Conn) {
 		ctx, cancel := context.WithCancel(c.Request.Context())
 		defer cancel()
+		if debug.mode != "" {
+			h.startDebug(ctx, c, cs, ws, namespace, podName, debug)
+			return
+		}
 		session := kube.NewTerminalSession(cs.K8sClient, ws, namespace, podName, container)
 		defer session.Close()
+		defer recordSession(c, session, &recording.Recording{
+			Kind:      recording.KindPod,
+			Namespace: namespace,
+			Pod:       podName,
+			Container: container,
+		})()
 
 		if err := session.Start(ctx, "exec"); err != nil {
 			klog.Errorf("Terminal session error: %v", err)
 		}
 	}).ServeHTTP(c.Writer, c.Request)
 }
+
+func (h *TerminalHandler) startDebug(ctx context.Context, c *gin.Context, cs *cluster.ClientSet, ws *websocket.Conn, namespace, podName string, debug debugOptions) {
+	defer func() {
+		_ = ws.Close()
+	}()
+	pod, err := cs.K8sClient.ClientSet.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		sendTerminalMessage(ws, "error", fmt.Sprintf("Failed to get pod %s: %v", podName, err))
+		return
+	}
+	if debug.target == "" {
+		debug.target = pod.Spec.Containers[0].Name
+	}
+
+	if debug.mode == debugModeCopy {
+		err = h.startCopyDebug(ctx, c, cs, ws, pod, debug)
+	} else {
+		err = h.startEphemeralDebug(ctx, c, cs, ws, pod, debug)
+	}
+	if err != nil {
+		klog.Errorf("Debug terminal session error: %v", err)
+		sendTerminalMessage(ws, "error", err.Error())
+	}
+}
@@ -1,12 +1,21 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/klog/v2"
 
+	"github.com/zxh326/kite/pkg/audit"
 	"github.com/zxh326/kite/pkg/cluster"
 	"github.com/zxh326/kite/pkg/common"
 	"github.com/zxh326/kite/pkg/handlers/resources"
+	"github.com/zxh326/kite/pkg/rbac"
+	"github.com/zxh326/kite/pkg/webhook"
 )
 
 type WebhookHandler struct {
@@ -28,31 +37,139 @@ func (h *WebhookHandler) HandleWebhook(c *gin.Context) {
 		return
 	}
 	klog.V(2).Infof("Received webhook request: %+v", body)
+	audit.SetTarget(c, body.Resource, body.Namespace, body.Name)
+
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	if err := authorizeWebhook(c, cs, body.Namespace, body.Resource, body.Action); err != nil {
+		c.JSON(403, gin.H{
+			"error": "Permission denied, " + err.Error(),
+		})
+		return
+	}
 	switch body.Action {
 	case common.ActionRestart:
-		handler, err := resources.GetHandler(body.Resource)
-		if err != nil {
-			c.JSON(400, gin.H{
-				"error": "Invalid resource type",
-			})
-			return
-		}
-		if restartable, ok := handler.(resources.Restartable); ok {
-			if err := restartable.Restart(c, body.Namespace, body.Name); err != nil {
-				c.JSON(500, gin.H{
-					"error": "Failed to restart resource: " + err.Error(),
-				})
-				return
-			}
-			c.JSON(200, gin.H{
-				"message": "Resource restarted",
+		if err := restartWorkload(c, body.Resource, body.Namespace, body.Name); err != nil {
+			c.JSON(500, gin.H{
+				"error": "Failed to restart resource: " + err.Error(),
 			})
 			return
 		}
+		c.JSON(200, gin.H{
+			"message": "Resource restarted",
+		})
 	case common.ActionUpdateImage:
+		h.updateImage(c, &body)
 	default:
 		c.JSON(400, gin.H{
 			"error": "Invalid action",
 		})
 	}
 }
+
+// authorizeWebhook checks the scope of the webhook token and the role of the
+// caller for an action on a workload, the error tells what is missing
+func authorizeWebhook(c *gin.Context, cs *cluster.ClientSet, namespace, resource string, action common.Action) error {
+	if token, ok := webhook.FromContext(c); ok {
+		if err := token.Allows(cs.Name, namespace, resource, string(action)); err != nil {
+			return err
+		}
+	}
+	// Webhook callers act as their token name, or the global webhook user, in
+	// the webhooks group
+	subject := rbac.Subject{Username: c.GetString(gin.AuthUserKey), Groups: []string{rbac.WebhookGroup}}
+	if !rbac.Authorize(subject, rbac.Request{
+		Cluster:   cs.Name,
+		Namespace: namespace,
+		Role:      rbac.RoleOperator,
+	}) {
+		return fmt.Errorf("webhook requires the operator role in namespace %s", namespace)
+	}
+	return nil
+}
+
+func restartWorkload(c *gin.Context, resource, namespace, name string) error {
+	handler, err := resources.GetHandler(resource)
+	if err != nil {
+		return err
+	}
+	restartable, ok := handler.(resources.Restartable)
+	if !ok {
+		return fmt.Errorf("%s can't be restarted", resource)
+	}
+	return restartable.Restart(c, namespace, name)
+}
+
+// defaultRolloutTimeout bounds the wait for a rollout after an image update
+const defaultRolloutTimeout = 5 * time.Minute
+
+func (h *WebhookHandler) updateImage(c *gin.Context, body *common.WebhookRequest) {
+	container, image, err := resources.ParseContainerImage(body.Data)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	ctx := c.Request.Context()
+	update, err := resources.UpdateImage(ctx, cs, body.Resource, body.Namespace, body.Name, container, image)
+	if err != nil {
+		status := 500
+		if apierrors.IsNotFound(err) {
+			status = 404
+		} else if errors.Is(err, resources.ErrContainerNotFound) {
+			status = 400
+		}
+		c.JSON(status, gin.H{
+			"error": "Failed to update image: " + err.Error(),
+		})
+		return
+	}
+	klog.Infof("Webhook updated image of %s %s/%s container %s: %s -> %s", body.Resource, body.Namespace, body.Name, container, update.PreviousImage, image)
+
+	response := gin.H{
+		"message":       "Image updated",
+		"container":     update.Container,
+		"previousImage": update.PreviousImage,
+		"image":         update.Image,
+		"changed":       update.Changed,
+	}
+	if !update.Changed {
+		response["message"] = "Image unchanged"
+	}
+	if !body.Wait {
+		c.JSON(200, response)
+		return
+	}
+
+	timeout := defaultRolloutTimeout
+	if body.TimeoutSeconds > 0 {
+		timeout = time.Duration(body.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := resources.WaitForRollout(ctx, cs, body.Resource, body.Namespace, body.Name, update.Generation); err != nil {
+		response["error"] = err.Error()
+		response["rolledOut"] = false
+		c.JSON(rolloutErrorStatus(err), response)
+		return
+	}
+	response["message"] = "Image updated and rolled out"
+	response["rolledOut"] = true
+	c.JSON(200, response)
+}
+
+// rolloutErrorStatus maps a failed wait for a rollout to a response status:
+// 504 when the wait timed out, 409 when the workload reports the rollout
+// failed and 500 otherwise
+func rolloutErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return 504
+	case errors.Is(err, resources.ErrRolloutFailed):
+		return 409
+	default:
+		return 500
+	}
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zxh326/kite/pkg/handlers/resources"
+)
+
+func TestRolloutErrorStatus(t *testing.T) {
+	assert.Equal(t, 504, rolloutErrorStatus(fmt.Errorf("rollout of deployments default/web did not complete: %w", context.DeadlineExceeded)))
+	assert.Equal(t, 409, rolloutErrorStatus(fmt.Errorf("%w: deployment web exceeded its progress deadline", resources.ErrRolloutFailed)))
+	assert.Equal(t, 500, rolloutErrorStatus(errors.New("connection refused")))
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zxh326/kite/pkg/webhook"
+)
+
+type createWebhookTokenRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Cluster    string   `json:"cluster"`
+	Namespaces []string `json:"namespaces"`
+	Resources  []string `json:"resources" binding:"dive,oneof=deployments statefulsets daemonsets clonesets"`
+	Actions    []string `json:"actions" binding:"dive,oneof=restart updateImage"`
+}
+
+type rotateWebhookTokenRequest struct {
+	// GracePeriodSeconds keeps the previous secret valid while pipelines
+	// are updated
+	GracePeriodSeconds int `json:"gracePeriodSeconds" binding:"min=0,max=604800"`
+}
+
+// ListWebhookTokens lists the webhook tokens without their secrets
+func ListWebhookTokens(c *gin.Context) {
+	tokens, err := webhook.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhook tokens: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": tokens})
+}
+
+// CreateWebhookToken creates a webhook token, its secret is only returned here
+func CreateWebhookToken(c *gin.Context) {
+	var req createWebhookTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	token, err := webhook.Create(&webhook.Token{
+		Name:       req.Name,
+		Cluster:    req.Cluster,
+		Namespaces: req.Namespaces,
+		Resources:  req.Resources,
+		Actions:    req.Actions,
+	})
+	switch {
+	case errors.Is(err, webhook.ErrExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, webhook.ErrInvalidName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook token: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+// RotateWebhookToken replaces the secret of a webhook token and returns it
+func RotateWebhookToken(c *gin.Context) {
+	var req rotateWebhookTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+	}
+	token, err := webhook.Rotate(c.Param("name"), time.Duration(req.GracePeriodSeconds)*time.Second)
+	if errors.Is(err, webhook.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate webhook token: " + err.Error()})
+		return
+	}
+	// Only the new secret is handed out
+	token.PreviousSecret = ""
+	c.JSON(http.StatusOK, token)
+}
+
+// RevokeWebhookToken deletes a webhook token
+func RevokeWebhookToken(c *gin.Context) {
+	err := webhook.Revoke(c.Param("name"))
+	if errors.Is(err, webhook.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke webhook token: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook token revoked"})
+}
@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/handlers/resources"
+)
+
+const (
+	// maxWorkloadLogStreams caps the log streams of one request, each is a
+	// connection to the API server
+	maxWorkloadLogStreams = 50
+	// workloadPodsPollInterval is how often a follow session looks for new pods
+	workloadPodsPollInterval = 5 * time.Second
+)
+
+// workloadSelector returns the pod selector of a workload
+func workloadSelector(c *gin.Context, resource, namespace, name string) (labels.Selector, error) {
+	obj, err := resources.GetResource(c, resource, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	var selector *metav1.LabelSelector
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		selector = workload.Spec.Selector
+	case *appsv1.StatefulSet:
+		selector = workload.Spec.Selector
+	case *appsv1.DaemonSet:
+		selector = workload.Spec.Selector
+	case *kruiseappsv1alpha1.CloneSet:
+		selector = workload.Spec.Selector
+	case *kruiseappsv1beta1.StatefulSet:
+		selector = workload.Spec.Selector
+	case *kruiseappsv1alpha1.DaemonSet:
+		selector = workload.Spec.Selector
+	default:
+		return nil, fmt.Errorf("resource %s has no pods to aggregate logs from", resource)
+	}
+	if selector == nil {
+		return nil, fmt.Errorf("%s %s has no selector", resource, name)
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
+func listWorkloadPods(ctx context.Context, cs *cluster.ClientSet, namespace string, selector labels.Selector) ([]corev1.Pod, error) {
+	var pods corev1.PodList
+	if err := cs.K8sClient.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	return pods.Items, nil
+}
+
+// logSource is one container of one pod
+type logSource struct {
+	pod       string
+	container string
+}
+
+func (s logSource) prefix() string {
+	return "[" + s.pod + "/" + s.container + "] "
+}
+
+// logSources lists the containers to read, optionally only the named one.
+// Only containers that ran are included, others have no logs yet.
+func logSources(pods []corev1.Pod, container string) []logSource {
+	var sources []logSource
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			if container != "" && status.Name != container {
+				continue
+			}
+			if status.State.Waiting != nil && status.LastTerminationState.Terminated == nil {
+				continue
+			}
+			sources = append(sources, logSource{pod: pod.Name, container: status.Name})
+		}
+	}
+	return sources
+}
+
+// containerInstances identifies the current run of every container, it
+// changes when a container restarts or is recreated
+func containerInstances(pods []corev1.Pod) map[logSource]string {
+	instances := make(map[logSource]string)
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			instances[logSource{pod: pod.Name, container: status.Name}] = fmt.Sprintf("%s/%d", status.ContainerID, status.RestartCount)
+		}
+	}
+	return instances
+}
+
+// streamFinished reports whether the container of an ended log stream is
+// gone or terminated, so the stream has nothing more to read
+func streamFinished(ctx context.Context, cs *cluster.ClientSet, namespace string, source logSource) bool {
+	var pod corev1.Pod
+	if err := cs.K8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: source.pod}, &pod); err != nil {
+		return apierrors.IsNotFound(err)
+	}
+	return containerTerminated(&pod, source.container)
+}
+
+func containerTerminated(pod *corev1.Pod, container string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.State.Terminated != nil
+		}
+	}
+	return true
+}
+
+// timestampedLine is a log line split into its RFC 3339 timestamp and message
+type timestampedLine struct {
+	time    time.Time
+	source  logSource
+	message string
+}
+
+func parseTimestampedLine(source logSource, line string) timestampedLine {
+	ts, message, ok := strings.Cut(line, " ")
+	if ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return timestampedLine{time: t, source: source, message: message}
+		}
+	}
+	return timestampedLine{source: source, message: line}
+}
+
+// format renders the line like stern, [pod/container] followed by the
+// timestamp when requested and the message
+func (l timestampedLine) format(timestamps bool) string {
+	if timestamps && !l.time.IsZero() {
+		return l.source.prefix() + l.time.Format(time.RFC3339Nano) + " " + l.message
+	}
+	return l.source.prefix() + l.message
+}
+
+// GetWorkloadLogs handles GET /workload-logs/:resource/:namespace/:name. It
+// reads the logs of every container of the pods selected by a deployment,
+// statefulset, daemonset or cloneset and interleaves them by timestamp. With
+// follow=true the lines are streamed as server-sent events and pods created
+// during the session are picked up.
+func (h *LogsHandler) GetWorkloadLogs(c *gin.Context) {
+	resource, namespace, name := c.Param("resource"), c.Param("namespace"), c.Param("name")
+	logOptions, err := parseLogOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	selector, err := workloadSelector(c, resource, namespace, name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cs := c.MustGet("cluster").(*cluster.ClientSet)
+	pods, err := listWorkloadPods(c.Request.Context(), cs, namespace, selector)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sources := logSources(pods, logOptions.Container)
+	truncated := len(sources) > maxWorkloadLogStreams
+	if truncated {
+		sources = sources[:maxWorkloadLogStreams]
+	}
+
+	if logOptions.Follow {
+		h.followWorkloadLogs(c, cs, namespace, selector, containerInstances(pods), sources, truncated, logOptions)
+		return
+	}
+
+	lines := make([]timestampedLine, 0)
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures = make(map[string]string)
+	)
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source logSource) {
+			defer wg.Done()
+			sourceLines, err := readContainerLogs(c.Request.Context(), cs, namespace, source, logOptions)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[source.pod+"/"+source.container] = err.Error()
+			}
+			lines = append(lines, sourceLines...)
+		}(source)
+	}
+	wg.Wait()
+
+	// Containers are read in parallel, ordering by time interleaves them
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].time.Before(lines[j].time)
+	})
+	logLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		logLines = append(logLines, line.format(logOptions.Timestamps))
+	}
+	podNames := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		podNames = append(podNames, pod.Name)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":      logLines,
+		"pods":      podNames,
+		"errors":    failures,
+		"truncated": truncated,
+		"namespace": namespace,
+	})
+}
+
+// readContainerLogs reads the logs of one container, timestamps are always
+// requested so lines of several containers can be merged
+func readContainerLogs(ctx context.Context, cs *cluster.ClientSet, namespace string, source logSource, logOptions *corev1.PodLogOptions) ([]timestampedLine, error) {
+	opts := logOptions.DeepCopy()
+	opts.Container = source.container
+	opts.Timestamps = true
+	opts.Follow = false
+	stream, err := cs.K8sClient.ClientSet.CoreV1().Pods(namespace).GetLogs(source.pod, opts).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	var lines []timestampedLine
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 8*1024), 64*1024)
+	for scanner.Scan() {
+		lines = append(lines, parseTimestampedLine(source, scanner.Text()))
+	}
+	return lines, scanner.Err()
+}
+
+// followWorkloadLogs streams the containers of the workload until the client
+// disconnects. Pods are re-listed periodically, streams are opened for new
+// containers and re-opened for restarted ones or ones that ended early. A
+// truncated event is sent when containers are left out because of the
+// stream limit.
+func (h *LogsHandler) followWorkloadLogs(c *gin.Context, cs *cluster.ClientSet, namespace string, selector labels.Selector, instances map[logSource]string, sources []logSource, truncated bool, logOptions *corev1.PodLogOptions) {
+	ctx := c.Request.Context()
+	timestamps := logOptions.Timestamps
+
+	type streamEvent struct {
+		event string
+		data  string
+	}
+	events := make(chan streamEvent, 256)
+	send := func(event, data string) {
+		if event == "error" {
+			b, _ := json.Marshal(gin.H{"error": data})
+			data = string(b)
+		}
+		select {
+		case events <- streamEvent{event: event, data: data}:
+		case <-ctx.Done():
+		}
+	}
+
+	sendTruncated := func() {
+		send("truncated", fmt.Sprintf(`{"truncated":true,"maxStreams":%d}`, maxWorkloadLogStreams))
+	}
+
+	var (
+		mu     sync.Mutex
+		active = map[logSource]bool{}
+		// ended records when a stream stopped, a re-opened stream continues from there
+		ended = map[logSource]time.Time{}
+		// opened records the container run each stream was opened for, streams
+		// of terminated containers are only re-opened once they restarted
+		opened = map[logSource]string{}
+	)
+	start := func(source logSource, instance string, opts *corev1.PodLogOptions) {
+		mu.Lock()
+		if active[source] {
+			mu.Unlock()
+			return
+		}
+		if seen, ok := opened[source]; ok && seen == instance {
+			mu.Unlock()
+			return
+		}
+		if len(active) >= maxWorkloadLogStreams {
+			notify := !truncated
+			truncated = true
+			mu.Unlock()
+			if notify {
+				sendTruncated()
+			}
+			return
+		}
+		active[source] = true
+		opened[source] = instance
+		mu.Unlock()
+
+		go func() {
+			defer func() {
+				mu.Lock()
+				delete(active, source)
+				ended[source] = time.Now()
+				mu.Unlock()
+			}()
+			opts := opts.DeepCopy()
+			opts.Container = source.container
+			opts.Timestamps = true
+			opts.Follow = true
+			stream, err := cs.K8sClient.ClientSet.CoreV1().Pods(namespace).GetLogs(source.pod, opts).Stream(ctx)
+			if err != nil {
+				// Try again on the next poll
+				mu.Lock()
+				delete(opened, source)
+				mu.Unlock()
+				send("error", source.prefix()+err.Error())
+				return
+			}
+			defer func() {
+				_ = stream.Close()
+			}()
+			send("source", source.pod+"/"+source.container)
+
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 8*1024), 64*1024)
+			for scanner.Scan() {
+				send("log", parseTimestampedLine(source, scanner.Text()).format(timestamps))
+			}
+			if err := scanner.Err(); err != nil && ctx.Err() == nil {
+				send("error", source.prefix()+err.Error())
+			}
+			// Streams also end while the container keeps running, e.g. when
+			// the API server closes them, those are re-opened on the next poll
+			if ctx.Err() == nil && !streamFinished(ctx, cs, namespace, source) {
+				mu.Lock()
+				delete(opened, source)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	if _, err := c.Writer.WriteString("event: connected\ndata: {\"status\":\"connected\"}\n\n"); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	if truncated {
+		sendTruncated()
+	}
+	for _, source := range sources {
+		start(source, instances[source], logOptions)
+	}
+
+	// Containers that appear later are read from their start
+	poll := func() {
+		pods, err := listWorkloadPods(ctx, cs, namespace, selector)
+		if err != nil {
+			send("error", err.Error())
+			return
+		}
+		instances := containerInstances(pods)
+		for _, source := range logSources(pods, logOptions.Container) {
+			opts := &corev1.PodLogOptions{}
+			mu.Lock()
+			if t, ok := ended[source]; ok {
+				opts.SinceTime = &metav1.Time{Time: t}
+			}
+			mu.Unlock()
+			start(source, instances[source], opts)
+		}
+	}
+
+	ticker := time.NewTicker(workloadPodsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			go poll()
+		case e := <-events:
+			if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", e.event, e.data); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLogSources(t *testing.T) {
+	pod := func(name string, statuses ...corev1.ContainerStatus) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.PodStatus{ContainerStatuses: statuses},
+		}
+	}
+	running := func(name string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: name, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	}
+	pending := corev1.ContainerStatus{Name: "app", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}}}
+	pods := []corev1.Pod{
+		pod("web-1", running("app"), running("sidecar")),
+		pod("web-2", pending),
+	}
+
+	assert.Equal(t, []logSource{
+		{pod: "web-1", container: "app"},
+		{pod: "web-1", container: "sidecar"},
+	}, logSources(pods, ""))
+	assert.Equal(t, []logSource{{pod: "web-1", container: "sidecar"}}, logSources(pods, "sidecar"))
+}
+
+func TestTimestampedLine(t *testing.T) {
+	source := logSource{pod: "web-1", container: "app"}
+	line := parseTimestampedLine(source, "2025-06-01T10:00:00.123456789Z GET /healthz 200")
+	assert.Equal(t, time.Date(2025, 6, 1, 10, 0, 0, 123456789, time.UTC), line.time)
+	assert.Equal(t, "[web-1/app] GET /healthz 200", line.format(false))
+	assert.Equal(t, "[web-1/app] 2025-06-01T10:00:00.123456789Z GET /healthz 200", line.format(true))
+
+	plain := parseTimestampedLine(source, "no timestamp here")
+	assert.True(t, plain.time.IsZero())
+	assert.Equal(t, "[web-1/app] no timestamp here", plain.format(true))
+}
+
+func TestContainerInstances(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "app", ContainerID: "containerd://a", RestartCount: 2},
+		}},
+	}
+	source := logSource{pod: "web-1", container: "app"}
+	before := containerInstances([]corev1.Pod{pod})[source]
+	assert.Equal(t, before, containerInstances([]corev1.Pod{pod})[source])
+
+	pod.Status.ContainerStatuses[0].RestartCount = 3
+	pod.Status.ContainerStatuses[0].ContainerID = "containerd://b"
+	assert.NotEqual(t, before, containerInstances([]corev1.Pod{pod})[source])
+}
+
+func TestContainerTerminated(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+		{Name: "app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		{Name: "migrate", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+	}}}
+	assert.False(t, containerTerminated(pod, "app"), "streams of running containers are re-opened")
+	assert.True(t, containerTerminated(pod, "migrate"))
+	assert.True(t, containerTerminated(pod, "removed"))
+}
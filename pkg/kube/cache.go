@@ -0,0 +1,245 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// CacheOptions configures the informer cache of a client
+type CacheOptions struct {
+	// Lazy defers starting the cache until EnsureCacheStarted is called
+	Lazy bool
+	// Kinds restricts the cached kinds, like Pod or Deployment, to cap memory.
+	// Other kinds are read from the API server, empty caches every kind.
+	Kinds []string
+}
+
+// CacheState is the lifecycle state of an informer cache
+type CacheState string
+
+const (
+	CacheStateDisabled CacheState = "disabled"
+	CacheStateIdle     CacheState = "idle"
+	CacheStateSyncing  CacheState = "syncing"
+	CacheStateSynced   CacheState = "synced"
+)
+
+// CacheStatus describes the informer cache of a client
+type CacheStatus struct {
+	State     CacheState
+	LastError string
+	// LastErrorTime is when the cache last failed to start or watch
+	LastErrorTime time.Time
+}
+
+// cacheState starts the controller-runtime manager at most once. Informers are
+// created on first read of a kind, so only pods, which carry the node index,
+// are synced up front.
+type cacheState struct {
+	mgr   manager.Manager
+	kinds []string
+
+	startMu sync.Mutex
+	started bool
+	stopped bool
+	stop    context.CancelFunc
+	synced  atomic.Bool
+	// done is closed on shutdown
+	done chan struct{}
+
+	mu            sync.Mutex
+	lastError     error
+	lastErrorTime time.Time
+}
+
+func (s *cacheState) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastError = err
+	s.lastErrorTime = time.Now()
+}
+
+func (s *cacheState) start() error {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if s.started {
+		return nil
+	}
+	if s.stopped {
+		return fmt.Errorf("informer cache is stopped")
+	}
+
+	// Add field indexer for Pod spec.nodeName to enable efficient querying by node
+	if cachesKind(s.kinds, "Pod") {
+		if err := s.mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, "spec.nodeName", func(rawObj client.Object) []string {
+			pod := rawObj.(*corev1.Pod)
+			if pod.Spec.NodeName == "" {
+				return nil
+			}
+			return []string{pod.Spec.NodeName}
+		}); err != nil {
+			err = fmt.Errorf("failed to create field indexer for spec.nodeName: %w", err)
+			s.recordError(err)
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if err := s.mgr.Start(ctx); err != nil {
+			klog.Errorf("Error starting manager: %v", err)
+			s.recordError(err)
+		}
+	}()
+	go func() {
+		if s.mgr.GetCache().WaitForCacheSync(ctx) {
+			s.synced.Store(true)
+		}
+	}()
+	s.stop = cancel
+	s.started = true
+	return nil
+}
+
+func (s *cacheState) shutdown() {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	close(s.done)
+	if s.stop != nil {
+		s.stop()
+	}
+}
+
+// EnsureCacheStarted starts the informer cache if it isn't running yet, reads
+// through the cached client fail until it is started
+func (k *K8sClient) EnsureCacheStarted() error {
+	if k.cache == nil {
+		return nil
+	}
+	return k.cache.start()
+}
+
+// Informer returns the shared informer of the kind of obj, waiting until it is
+// synced. ok is false when the kind isn't cached, use Watch instead.
+func (k *K8sClient) Informer(ctx context.Context, obj client.Object) (informer cache.Informer, ok bool, err error) {
+	if k.cache == nil {
+		return nil, false, nil
+	}
+	gvk, err := apiutil.GVKForObject(obj, runtimeScheme)
+	if err != nil {
+		return nil, false, err
+	}
+	if !cachesKind(k.cache.kinds, gvk.Kind) {
+		return nil, false, nil
+	}
+	if err := k.EnsureCacheStarted(); err != nil {
+		return nil, false, err
+	}
+	informer, err = k.cache.mgr.GetCache().GetInformer(ctx, obj)
+	return informer, true, err
+}
+
+// Watch watches objects on the API server directly, bypassing the cache
+func (k *K8sClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	c, err := client.NewWithWatch(k.Configuration, client.Options{Scheme: runtimeScheme})
+	if err != nil {
+		return nil, err
+	}
+	return c.Watch(ctx, list, opts...)
+}
+
+// CacheStatus reports the state of the informer cache and its latest error
+func (k *K8sClient) CacheStatus() CacheStatus {
+	if k.cache == nil {
+		return CacheStatus{State: CacheStateDisabled}
+	}
+	status := CacheStatus{State: CacheStateIdle}
+	k.cache.startMu.Lock()
+	if k.cache.started {
+		status.State = CacheStateSyncing
+		if k.cache.synced.Load() {
+			status.State = CacheStateSynced
+		}
+	}
+	k.cache.startMu.Unlock()
+
+	k.cache.mu.Lock()
+	defer k.cache.mu.Unlock()
+	if k.cache.lastError != nil {
+		status.LastError = k.cache.lastError.Error()
+		status.LastErrorTime = k.cache.lastErrorTime
+	}
+	return status
+}
+
+// Done is closed when the informer cache is stopped, it is nil for clients
+// without a cache, which never stop
+func (k *K8sClient) Done() <-chan struct{} {
+	if k.cache == nil {
+		return nil
+	}
+	return k.cache.done
+}
+
+// Stop shuts down the informer cache of the client, it must not be used afterwards
+func (k *K8sClient) Stop() {
+	if k.cache != nil {
+		k.cache.shutdown()
+	}
+}
+
+func cachesKind(kinds []string, kind string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if strings.EqualFold(strings.TrimSpace(k), kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// uncachedObjects lists an object of every registered kind that isn't in
+// kinds, so the client reads them from the API server
+func uncachedObjects(kinds []string) []client.Object {
+	if len(kinds) == 0 {
+		return nil
+	}
+	seen := make(map[reflect.Type]bool)
+	var objects []client.Object
+	for gvk, t := range runtimeScheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal || strings.HasSuffix(gvk.Kind, "List") || seen[t] || cachesKind(kinds, gvk.Kind) {
+			continue
+		}
+		obj, ok := reflect.New(t).Interface().(client.Object)
+		if !ok {
+			continue
+		}
+		// Types registered under several kinds can't be resolved by the client
+		if _, err := apiutil.GVKForObject(obj, runtimeScheme); err != nil {
+			continue
+		}
+		seen[t] = true
+		objects = append(objects, obj)
+	}
+	return objects
+}
@@ -0,0 +1,39 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestUncachedObjects(t *testing.T) {
+	assert.Nil(t, uncachedObjects(nil))
+
+	uncached := make(map[reflect.Type]bool)
+	for _, obj := range uncachedObjects([]string{"pod", " Node"}) {
+		uncached[reflect.TypeOf(obj)] = true
+	}
+	assert.False(t, uncached[reflect.TypeOf(&corev1.Pod{})])
+	assert.False(t, uncached[reflect.TypeOf(&corev1.Node{})])
+	assert.True(t, uncached[reflect.TypeOf(&appsv1.Deployment{})])
+}
+
+func TestCacheStatusDisabled(t *testing.T) {
+	assert.Equal(t, CacheStateDisabled, (&K8sClient{}).CacheStatus().State)
+}
+
+func TestDoneClosedOnStop(t *testing.T) {
+	assert.Nil(t, (&K8sClient{}).Done())
+
+	k := &K8sClient{cache: &cacheState{done: make(chan struct{})}}
+	k.Stop()
+	k.Stop()
+	select {
+	case <-k.Done():
+	default:
+		t.Fatal("Done is not closed after Stop")
+	}
+}
@@ -16,7 +16,6 @@ import (
 
 	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
 	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
-	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	toolscache "k8s.io/client-go/tools/cache"
 	metricsv1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
@@ -45,10 +44,13 @@ type K8sClient struct {
 	ClientSet     *kubernetes.Clientset
 	Configuration *rest.Config
 	MetricsClient *metricsclient.Clientset
+
+	// cache is the informer cache state, nil when caching is disabled
+	cache *cacheState
 }
 
 // NewClient creates a K8sClient from a rest.Config
-func NewClient(config *rest.Config) (*K8sClient, error) {
+func NewClient(config *rest.Config, opts CacheOptions) (*K8sClient, error) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
@@ -59,7 +61,10 @@ func NewClient(config *rest.Config) (*K8sClient, error) {
 		klog.Warningf("failed to create metrics client: %v", err)
 	}
 
-	var c client.Client
+	var (
+		c     client.Client
+		state *cacheState
+	)
 	if os.Getenv("DISABLE_CACHE") == "true" {
 		c, err = client.New(config, client.Options{
 			Scheme: runtimeScheme,
@@ -68,6 +73,7 @@ func NewClient(config *rest.Config) (*K8sClient, error) {
 			return nil, fmt.Errorf("failed to create client: %w", err)
 		}
 	} else {
+		state = &cacheState{kinds: opts.Kinds, done: make(chan struct{})}
 		mgr, err := manager.New(config, manager.Options{
 			Scheme:         runtimeScheme,
 			LeaderElection: false,
@@ -76,35 +82,62 @@ func NewClient(config *rest.Config) (*K8sClient, error) {
 			},
 			Cache: cache.Options{
 				DefaultWatchErrorHandler: func(ctx context.Context, r *toolscache.Reflector, err error) {
+					state.recordError(err)
+				},
+			},
+			Client: client.Options{
+				Cache: &client.CacheOptions{
+					DisableFor: uncachedObjects(opts.Kinds),
 				},
 			},
 		})
 		if err != nil {
 			return nil, err
 		}
-
-		// Add field indexer for Pod spec.nodeName to enable efficient querying by node
-		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, "spec.nodeName", func(rawObj client.Object) []string {
-			pod := rawObj.(*corev1.Pod)
-			if pod.Spec.NodeName == "" {
-				return nil
-			}
-			return []string{pod.Spec.NodeName}
-		}); err != nil {
-			return nil, fmt.Errorf("failed to create field indexer for spec.nodeName: %w", err)
-		}
-
-		go func() {
-			if err := mgr.Start(context.Background()); err != nil {
-				fmt.Printf("Error starting manager: %v\n", err)
+		state.mgr = mgr
+		if !opts.Lazy {
+			if err := state.start(); err != nil {
+				// Retried when the client is first used
+				klog.Warningf("Failed to start informer cache: %v", err)
 			}
-		}()
-		if !mgr.GetCache().WaitForCacheSync(context.Background()) {
-			return nil, fmt.Errorf("failed to wait for cache sync")
 		}
 		c = mgr.GetClient()
 	}
 
+	return &K8sClient{
+		Client:        c,
+		ClientSet:     clientset,
+		Configuration: config,
+		MetricsClient: metricsClient,
+		cache:         state,
+	}, nil
+}
+
+// Impersonate creates a K8sClient that acts as the given user and groups.
+// It talks to the API server directly instead of using the shared informer
+// cache, so the cluster RBAC is enforced on reads as well as writes.
+func (k *K8sClient) Impersonate(username string, groups []string) (*K8sClient, error) {
+	config := rest.CopyConfig(k.Configuration)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: username,
+		Groups:   groups,
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(config, client.Options{
+		Scheme: runtimeScheme,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create impersonated client: %w", err)
+	}
+	metricsClient, err := metricsclient.NewForConfig(config)
+	if err != nil {
+		klog.Warningf("failed to create impersonated metrics client: %v", err)
+	}
+
 	return &K8sClient{
 		Client:        c,
 		ClientSet:     clientset,
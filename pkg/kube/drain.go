@@ -0,0 +1,310 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MirrorPodAnnotation marks static pods mirrored by the kubelet, they can't be evicted
+	MirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+	defaultDrainTimeout  = 2 * time.Minute
+	evictionRetryBackoff = 5 * time.Second
+)
+
+// DrainOptions controls how pods are removed from a node
+type DrainOptions struct {
+	// Force allows deleting pods that are not managed by a controller
+	Force bool `json:"force"`
+	// GracePeriod overrides the pod termination grace period in seconds, 0 uses the pod default
+	GracePeriod int `json:"gracePeriod"`
+	// DeleteLocalData allows evicting pods using emptyDir volumes
+	DeleteLocalData bool `json:"deleteLocalData"`
+	// IgnoreDaemonsets skips DaemonSet managed pods instead of failing
+	IgnoreDaemonsets bool `json:"ignoreDaemonsets"`
+	// Timeout is the maximum time to wait for evictions, 0 uses the default
+	Timeout time.Duration `json:"-"`
+}
+
+// DrainPodStatus is the outcome of draining a single pod
+type DrainPodStatus string
+
+const (
+	DrainPodEvicted DrainPodStatus = "evicted"
+	DrainPodDeleted DrainPodStatus = "deleted"
+	DrainPodSkipped DrainPodStatus = "skipped"
+	DrainPodBlocked DrainPodStatus = "blocked"
+	DrainPodFailed  DrainPodStatus = "failed"
+)
+
+// DrainPodResult reports what happened to a pod during drain
+type DrainPodResult struct {
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace"`
+	Status    DrainPodStatus `json:"status"`
+	Message   string         `json:"message,omitempty"`
+}
+
+// DrainEventType identifies a step of a drain operation
+type DrainEventType string
+
+const (
+	DrainEventCordoned   DrainEventType = "cordoned"
+	DrainEventEvicted    DrainEventType = "evicted"
+	DrainEventDeleted    DrainEventType = "deleted"
+	DrainEventSkipped    DrainEventType = "skipped"
+	DrainEventBlocked    DrainEventType = "blocked"
+	DrainEventPDBBlocked DrainEventType = "pdbBlocked"
+	DrainEventRetry      DrainEventType = "retry"
+	DrainEventFailed     DrainEventType = "failed"
+)
+
+// DrainEvent is emitted for every step of a drain so callers can report progress
+type DrainEvent struct {
+	Type      DrainEventType `json:"type"`
+	Node      string         `json:"node"`
+	Pod       string         `json:"pod,omitempty"`
+	Namespace string         `json:"namespace,omitempty"`
+	Message   string         `json:"message,omitempty"`
+	Time      time.Time      `json:"time"`
+}
+
+// DrainResult summarizes a drain operation
+type DrainResult struct {
+	Node    string           `json:"node"`
+	Success bool             `json:"success"`
+	Pods    []DrainPodResult `json:"pods"`
+}
+
+// Drainer cordons a node and evicts its pods through the Eviction API
+type Drainer struct {
+	client  *K8sClient
+	options DrainOptions
+	onEvent func(DrainEvent)
+}
+
+func NewDrainer(client *K8sClient, options DrainOptions) *Drainer {
+	if options.Timeout <= 0 {
+		options.Timeout = defaultDrainTimeout
+	}
+	return &Drainer{
+		client:  client,
+		options: options,
+	}
+}
+
+// OnEvent registers a handler called synchronously for every drain event
+func (d *Drainer) OnEvent(handler func(DrainEvent)) *Drainer {
+	d.onEvent = handler
+	return d
+}
+
+func (d *Drainer) emit(eventType DrainEventType, nodeName string, pod *corev1.Pod, message string) {
+	if d.onEvent == nil {
+		return
+	}
+	event := DrainEvent{
+		Type:    eventType,
+		Node:    nodeName,
+		Message: message,
+		Time:    time.Now(),
+	}
+	if pod != nil {
+		event.Pod = pod.Name
+		event.Namespace = pod.Namespace
+	}
+	d.onEvent(event)
+}
+
+// record appends a pod result and emits the matching event
+func (d *Drainer) record(result *DrainResult, podResult DrainPodResult, pod *corev1.Pod) {
+	result.Pods = append(result.Pods, podResult)
+	d.emit(DrainEventType(podResult.Status), result.Node, pod, podResult.Message)
+}
+
+// podDrainAction describes how a pod should be handled during drain
+type podDrainAction int
+
+const (
+	podActionEvict podDrainAction = iota
+	podActionDelete
+	podActionSkip
+	podActionBlock
+)
+
+// classifyPod decides what to do with a pod, mirroring the kubectl drain filters
+func classifyPod(pod *corev1.Pod, options DrainOptions) (podDrainAction, string) {
+	if _, ok := pod.Annotations[MirrorPodAnnotation]; ok {
+		return podActionSkip, "mirror pod"
+	}
+	if pod.DeletionTimestamp != nil {
+		return podActionSkip, "pod is already terminating"
+	}
+	// Finished pods have nothing running, deleting them is always safe
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return podActionDelete, "pod has completed"
+	}
+
+	controller := metav1.GetControllerOf(pod)
+	if controller != nil && controller.Kind == "DaemonSet" {
+		if options.IgnoreDaemonsets {
+			return podActionSkip, "managed by DaemonSet"
+		}
+		return podActionBlock, "managed by DaemonSet, set ignoreDaemonsets to skip it"
+	}
+	if controller == nil && !options.Force {
+		return podActionBlock, "not managed by a controller, set force to delete it"
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil && !options.DeleteLocalData {
+			return podActionBlock, "uses emptyDir local storage, set deleteLocalData to delete it"
+		}
+	}
+	return podActionEvict, ""
+}
+
+// Drain cordons the node, then evicts all evictable pods. Pods blocking the
+// drain (unmanaged, DaemonSet or local data without the matching option) abort
+// the operation before any pod is evicted.
+func (d *Drainer) Drain(ctx context.Context, nodeName string) (*DrainResult, error) {
+	var pods corev1.PodList
+	if err := d.client.List(ctx, &pods, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+	}
+
+	result := &DrainResult{Node: nodeName, Pods: make([]DrainPodResult, 0, len(pods.Items))}
+	var toEvict, toDelete []*corev1.Pod
+	blocked := false
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		action, reason := classifyPod(pod, d.options)
+		switch action {
+		case podActionEvict:
+			toEvict = append(toEvict, pod)
+		case podActionDelete:
+			toDelete = append(toDelete, pod)
+		case podActionSkip:
+			d.record(result, newDrainPodResult(pod, DrainPodSkipped, reason), pod)
+		case podActionBlock:
+			blocked = true
+			d.record(result, newDrainPodResult(pod, DrainPodBlocked, reason), pod)
+		}
+	}
+	if blocked {
+		return result, fmt.Errorf("cannot drain node %s, some pods block the drain", nodeName)
+	}
+
+	if err := d.cordon(ctx, nodeName); err != nil {
+		return nil, fmt.Errorf("failed to cordon node %s: %w", nodeName, err)
+	}
+	d.emit(DrainEventCordoned, nodeName, nil, "")
+
+	ctx, cancel := context.WithTimeout(ctx, d.options.Timeout)
+	defer cancel()
+
+	for _, pod := range toDelete {
+		d.record(result, d.deletePod(ctx, pod), pod)
+	}
+	for _, pod := range toEvict {
+		if err := ctx.Err(); err != nil {
+			// Cancelled or timed out, don't start any further eviction
+			d.record(result, newDrainPodResult(pod, DrainPodFailed, "eviction aborted: "+err.Error()), pod)
+			continue
+		}
+		d.record(result, d.evictPod(ctx, pod), pod)
+	}
+
+	result.Success = true
+	for _, pod := range result.Pods {
+		if pod.Status == DrainPodFailed {
+			result.Success = false
+			break
+		}
+	}
+	return result, nil
+}
+
+func (d *Drainer) cordon(ctx context.Context, nodeName string) error {
+	var node corev1.Node
+	if err := d.client.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
+		return err
+	}
+	if node.Spec.Unschedulable {
+		return nil
+	}
+	node.Spec.Unschedulable = true
+	return d.client.Update(ctx, &node)
+}
+
+func (d *Drainer) deleteOptions() metav1.DeleteOptions {
+	opts := metav1.DeleteOptions{}
+	if d.options.GracePeriod > 0 {
+		gracePeriod := int64(d.options.GracePeriod)
+		opts.GracePeriodSeconds = &gracePeriod
+	}
+	return opts
+}
+
+func (d *Drainer) deletePod(ctx context.Context, pod *corev1.Pod) DrainPodResult {
+	err := d.client.ClientSet.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, d.deleteOptions())
+	if err != nil && !apierrors.IsNotFound(err) {
+		return newDrainPodResult(pod, DrainPodFailed, err.Error())
+	}
+	return newDrainPodResult(pod, DrainPodDeleted, "")
+}
+
+// evictPod evicts a pod, retrying while a PodDisruptionBudget rejects the eviction
+func (d *Drainer) evictPod(ctx context.Context, pod *corev1.Pod) DrainPodResult {
+	deleteOptions := d.deleteOptions()
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+		DeleteOptions: &deleteOptions,
+	}
+
+	for {
+		err := d.client.ClientSet.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+		switch {
+		case err == nil, apierrors.IsNotFound(err):
+			return newDrainPodResult(pod, DrainPodEvicted, "")
+		case apierrors.IsTooManyRequests(err):
+			// The eviction would violate a PodDisruptionBudget, wait and retry
+			klog.V(2).Infof("Eviction of pod %s/%s blocked by disruption budget: %v", pod.Namespace, pod.Name, err)
+			d.emit(DrainEventPDBBlocked, pod.Spec.NodeName, pod, err.Error())
+		default:
+			return newDrainPodResult(pod, DrainPodFailed, err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return newDrainPodResult(pod, DrainPodFailed, fmt.Sprintf("eviction aborted (%v) while blocked by disruption budget: %v", ctx.Err(), err))
+		case <-time.After(evictionRetryBackoff):
+			d.emit(DrainEventRetry, pod.Spec.NodeName, pod, "retrying eviction")
+		}
+	}
+}
+
+func newDrainPodResult(pod *corev1.Pod, status DrainPodStatus, message string) DrainPodResult {
+	return DrainPodResult{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Status:    status,
+		Message:   message,
+	}
+}
@@ -0,0 +1,64 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestPod(ownerKind string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if ownerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{
+			{Kind: ownerKind, Name: "owner", Controller: &controller},
+		}
+	}
+	return pod
+}
+
+func TestClassifyPod(t *testing.T) {
+	mirrorPod := newTestPod("")
+	mirrorPod.Annotations = map[string]string{MirrorPodAnnotation: "hash"}
+
+	completedPod := newTestPod("")
+	completedPod.Status.Phase = corev1.PodSucceeded
+
+	localDataPod := newTestPod("ReplicaSet")
+	localDataPod.Spec.Volumes = []corev1.Volume{
+		{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		options  DrainOptions
+		expected podDrainAction
+	}{
+		{"managed pod is evicted", newTestPod("ReplicaSet"), DrainOptions{}, podActionEvict},
+		{"mirror pod is skipped", mirrorPod, DrainOptions{Force: true}, podActionSkip},
+		{"completed pod is deleted", completedPod, DrainOptions{}, podActionDelete},
+		{"daemonset pod blocks by default", newTestPod("DaemonSet"), DrainOptions{}, podActionBlock},
+		{"daemonset pod is skipped when ignored", newTestPod("DaemonSet"), DrainOptions{IgnoreDaemonsets: true}, podActionSkip},
+		{"unmanaged pod blocks without force", newTestPod(""), DrainOptions{}, podActionBlock},
+		{"unmanaged pod is evicted with force", newTestPod(""), DrainOptions{Force: true}, podActionEvict},
+		{"local data blocks by default", localDataPod, DrainOptions{}, podActionBlock},
+		{"local data is evicted when allowed", localDataPod, DrainOptions{DeleteLocalData: true}, podActionEvict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, _ := classifyPod(tt.pod, tt.options)
+			assert.Equal(t, tt.expected, action)
+		})
+	}
+}
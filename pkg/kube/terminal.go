@@ -24,6 +24,13 @@ type TerminalMessage struct {
 	Cols uint16 `json:"cols,omitempty"`
 }
 
+// TerminalRecorder receives the streams of a terminal session
+type TerminalRecorder interface {
+	Input(data []byte)
+	Output(data []byte)
+	Resize(cols, rows uint16)
+}
+
 // TerminalSession manages a WebSocket connection for terminal communication
 type TerminalSession struct {
 	k8sClient *K8sClient
@@ -32,6 +39,7 @@ type TerminalSession struct {
 	namespace string
 	podName   string
 	container string
+	recorder  TerminalRecorder
 
 	lastHeartbeat time.Time // Track last heartbeat for ping/pong
 }
@@ -47,6 +55,11 @@ func NewTerminalSession(client *K8sClient, conn *websocket.Conn, namespace, podN
 	}
 }
 
+// SetRecorder records the session, it must be called before Start
+func (session *TerminalSession) SetRecorder(recorder TerminalRecorder) {
+	session.recorder = recorder
+}
+
 func (session *TerminalSession) Start(ctx context.Context, subResource string) error {
 	req := session.k8sClient.ClientSet.CoreV1().RESTClient().Post().
 		Resource("pods").
@@ -111,9 +124,16 @@ func (session *TerminalSession) Read(p []byte) (int, error) {
 	switch msg.Type {
 	case "stdin":
 		data := []byte(msg.Data)
-		return copy(p, data), nil
+		n := copy(p, data)
+		if session.recorder != nil {
+			session.recorder.Input(data[:n])
+		}
+		return n, nil
 	case "resize":
 		if msg.Rows > 0 && msg.Cols > 0 {
+			if session.recorder != nil {
+				session.recorder.Resize(msg.Cols, msg.Rows)
+			}
 			select {
 			case session.sizeChan <- &remotecommand.TerminalSize{
 				Width:  msg.Cols,
@@ -141,6 +161,9 @@ func (session *TerminalSession) Write(p []byte) (int, error) {
 		log.Printf("Write stdout error: %v", err)
 		return 0, err
 	}
+	if session.recorder != nil {
+		session.recorder.Output(p)
+	}
 	return len(p), nil
 }
 
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zxh326/kite/pkg/audit"
+	"github.com/zxh326/kite/pkg/utils"
+)
+
+// maxAuditCapture bounds the request and error bodies kept in audit entries
+const maxAuditCapture = 4096
+
+// errorCaptureWriter keeps the beginning of error responses for the audit entry
+type errorCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *errorCaptureWriter) capture(data []byte) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < maxAuditCapture {
+		w.body.Write(data[:min(len(data), maxAuditCapture-w.body.Len())])
+	}
+}
+
+func (w *errorCaptureWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// AuditMiddleware records mutating requests and terminal sessions. It must run
+// after RequireAuth and ClusterMiddleware, and before RBACMiddleware so denied
+// requests are recorded too.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		verb := auditVerb(c.Request.Method, c.FullPath())
+		if verb == "" {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		entry := &audit.Entry{
+			ID:        utils.RandomString(16),
+			Time:      start,
+			ClientIP:  c.ClientIP(),
+			Cluster:   c.GetString(ClusterNameKey),
+			Namespace: strings.TrimPrefix(c.Param("namespace"), "_all"),
+			Resource:  auditResource(c),
+			Name:      firstParam(c, "name", "podName", "nodeName", "id"),
+			Verb:      verb,
+		}
+		entry.User, entry.Provider = RequestUser(c)
+		if verb == "exec" {
+			entry.Request = "container=" + c.Query("container")
+		} else if captureAuditRequest(verb) && c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditCapture+1))
+			if err == nil {
+				c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+				entry.Request = string(body[:min(len(body), maxAuditCapture)])
+			}
+		}
+		audit.NewContext(c, entry)
+
+		writer := &errorCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		entry.DurationMs = time.Since(start).Milliseconds()
+		entry.StatusCode = c.Writer.Status()
+		entry.Success = entry.StatusCode < http.StatusBadRequest
+		if !entry.Success {
+			var resp struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(writer.body.Bytes(), &resp); err == nil && resp.Error != "" {
+				entry.Error = resp.Error
+			} else {
+				entry.Error = writer.body.String()
+			}
+		}
+		audit.Write(entry)
+	}
+}
+
+// RequestUser returns the name and auth provider of the user of a request,
+// webhook callers are reported with the webhook provider
+func RequestUser(c *gin.Context) (name, provider string) {
+	if user, ok := c.Get("user"); ok {
+		u := user.(gin.H)
+		name, _ = u["username"].(string)
+		if name == "" {
+			name, _ = u["name"].(string)
+		}
+		provider, _ = u["provider"].(string)
+		return name, provider
+	}
+	if webhookUser := c.GetString(gin.AuthUserKey); webhookUser != "" {
+		return webhookUser, "webhook"
+	}
+	return "", ""
+}
+
+// auditVerb names the action of a route, empty for requests that aren't audited
+func auditVerb(method, fullPath string) string {
+	switch {
+	case strings.HasPrefix(fullPath, "/api/v1/node-terminal/"):
+		return "node-exec"
+	case strings.HasPrefix(fullPath, "/api/v1/terminal/"):
+		return "exec"
+	case method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions:
+		return ""
+	case fullPath == "/api/v1/clusters/test", fullPath == "/api/v1/clusters/:name/health":
+		// Connectivity and health checks change nothing, test bodies hold credentials
+		return ""
+	case fullPath == "/api/v1/resources/apply":
+		return "apply"
+	case strings.HasPrefix(fullPath, "/api/v1/webhooks/"):
+		return "webhook"
+	case fullPath == "/api/v1/version/upgrade":
+		return "upgrade"
+	case method == http.MethodPut:
+		return "update"
+	case method == http.MethodPatch:
+		return "patch"
+	case method == http.MethodDelete:
+		return "delete"
+	}
+
+	segments := strings.Split(strings.Trim(fullPath, "/"), "/")
+	if last := segments[len(segments)-1]; len(segments) > 1 && segments[len(segments)-2] == ":name" {
+		// Operations like POST /deployments/:namespace/:name/restart
+		return last
+	}
+	return "create"
+}
+
+// captureAuditRequest reports whether the request body is small and safe to
+// store, manifests may contain secrets and are tracked as diffs instead
+func captureAuditRequest(verb string) bool {
+	switch verb {
+	case "create", "update", "patch", "apply", "exec", "node-exec":
+		return false
+	}
+	return true
+}
+
+func auditResource(c *gin.Context) string {
+	fullPath := c.FullPath()
+	switch {
+	case strings.HasPrefix(fullPath, "/api/v1/node-terminal/"):
+		return "nodes"
+	case strings.HasPrefix(fullPath, "/api/v1/terminal/"):
+		return "pods"
+	case c.Param("crd") != "":
+		return c.Param("crd")
+	}
+	segments := strings.Split(strings.TrimPrefix(fullPath, "/api/v1/"), "/")
+	return segments[0]
+}
+
+func firstParam(c *gin.Context, keys ...string) string {
+	for _, key := range keys {
+		if v := c.Param(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditVerb(t *testing.T) {
+	tests := []struct {
+		method   string
+		fullPath string
+		expected string
+	}{
+		{http.MethodGet, "/api/v1/pods/:namespace/:name", ""},
+		{http.MethodPost, "/api/v1/pods/:namespace", "create"},
+		{http.MethodPut, "/api/v1/pods/:namespace/:name", "update"},
+		{http.MethodDelete, "/api/v1/pods/:namespace/:name", "delete"},
+		{http.MethodPost, "/api/v1/deployments/:namespace/:name/scale", "scale"},
+		{http.MethodPost, "/api/v1/nodes/_all/:name/taint", "taint"},
+		{http.MethodPost, "/api/v1/resources/apply", "apply"},
+		{http.MethodGet, "/api/v1/terminal/:namespace/:podName/ws", "exec"},
+		{http.MethodGet, "/api/v1/node-terminal/:nodeName/ws", "node-exec"},
+		{http.MethodPost, "/api/v1/clusters/test", ""},
+		{http.MethodPost, "/api/v1/webhooks/registry/:format", "webhook"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.fullPath, func(t *testing.T) {
+			assert.Equal(t, tt.expected, auditVerb(tt.method, tt.fullPath))
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
 
 	"github.com/zxh326/kite/pkg/cluster"
+	"github.com/zxh326/kite/pkg/common"
 )
 
 const (
@@ -28,8 +32,48 @@ func ClusterMiddleware(cm *cluster.ClusterManager) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		c.Set("cluster", cluster)
 		c.Set(ClusterNameKey, cluster.Name)
+		if cluster, err = ClientSetForRequest(c, cluster); err != nil {
+			c.JSON(500, gin.H{"error": "Failed to create impersonated client: " + err.Error()})
+			c.Abort()
+			return
+		}
+		c.Set("cluster", cluster)
 		c.Next()
 	}
 }
+
+// ClientSetForRequest prepares a cluster for use by the request: it starts a
+// lazy cache and impersonates the logged-in user when impersonation is enabled.
+// Handlers that fan out to several clusters call it for each of them.
+func ClientSetForRequest(c *gin.Context, cs *cluster.ClientSet) (*cluster.ClientSet, error) {
+	// Lazy caches start on first use, reads block until they are synced
+	if err := cs.K8sClient.EnsureCacheStarted(); err != nil {
+		klog.Warningf("Failed to start informer cache of cluster %s: %v", cs.Name, err)
+	}
+	if !common.EnableImpersonation {
+		return cs, nil
+	}
+	user, ok := c.Get("user")
+	if !ok {
+		return cs, nil
+	}
+	return impersonate(cs, user.(gin.H))
+}
+
+// impersonate derives a ClientSet acting as the logged-in user. Anonymous
+// users (no login method enabled) keep Kite's own identity.
+func impersonate(cs *cluster.ClientSet, user gin.H) (*cluster.ClientSet, error) {
+	if provider, _ := user["provider"].(string); provider == "none" {
+		return cs, nil
+	}
+	username, _ := user["username"].(string)
+	if username == "" {
+		username, _ = user["name"].(string)
+	}
+	if username == "" {
+		return nil, fmt.Errorf("user has no username to impersonate")
+	}
+	groups, _ := user["groups"].([]string)
+	return cs.ForUser(username, groups)
+}
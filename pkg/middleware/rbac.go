@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+
+	"github.com/zxh326/kite/pkg/rbac"
+)
+
+// clusterInfoPaths only need a role somewhere in the cluster, so namespace
+// scoped users can still load the dashboard. They must not reveal objects of
+// other namespaces: the overview only returns counts and search filters its
+// results per namespace.
+var clusterInfoPaths = map[string]bool{
+	"/api/v1/overview":                          true,
+	"/api/v1/clusters":                          true,
+	"/api/v1/permissions":                       true,
+	"/api/v1/search":                            true,
+	"/api/v1/prometheus/resource-usage-history": true,
+	"/api/v1/openkruise/status":                 true,
+	"/api/v1/tailscale/status":                  true,
+	"/api/v1/system-upgrade/status":             true,
+	"/api/v1/traefik/status":                    true,
+}
+
+// MultiClusterPathPrefix is the prefix of routes that run across several clusters
+const MultiClusterPathPrefix = "/api/v1/multicluster/"
+
+// RBACMiddleware checks the built-in role of the user for the matched route.
+// It must run after RequireAuth and ClusterMiddleware.
+func RBACMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Fan-out routes authorize every cluster they touch themselves
+		if !rbac.Enabled() || strings.HasPrefix(c.FullPath(), MultiClusterPathPrefix) {
+			c.Next()
+			return
+		}
+		user, ok := c.Get("user")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			return
+		}
+		subject := rbac.SubjectFromUser(user.(gin.H))
+		req := newRBACRequest(c.Request.Method, c.FullPath(), c.Param("namespace"), c.Param("name"))
+		if req.Cluster == "" {
+			req.Cluster = c.GetString(ClusterNameKey)
+		}
+
+		if !rbac.Authorize(subject, req) {
+			klog.V(2).Infof("RBAC denied %s %s for user %s (requires %s)", c.Request.Method, c.Request.URL.Path, subject.Username, req.Role)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Permission denied, this action requires the " + string(req.Role) + " role",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// newRBACRequest maps a route to the role it requires, name is the :name
+// parameter of the route
+func newRBACRequest(method, fullPath, namespace, name string) rbac.Request {
+	if namespace == "_all" {
+		namespace = ""
+	}
+	req := rbac.Request{Namespace: namespace, Role: rbac.RoleAdmin}
+
+	switch {
+	case strings.HasPrefix(fullPath, "/api/v1/node-terminal/"):
+		req.Role = rbac.RoleAdmin
+	case strings.HasPrefix(fullPath, "/api/v1/terminal/"):
+		req.Role = rbac.RoleOperator
+	case fullPath == "/api/v1/audit", strings.HasPrefix(fullPath, "/api/v1/recordings"):
+		req.Role = rbac.RoleAdmin
+	case fullPath == "/api/v1/clusters/:name/health":
+		// Re-checking health is read-only, like listing clusters. It needs a
+		// role in the checked cluster, not the one the request was sent for
+		req.Role = rbac.RoleViewer
+		req.AnyNamespace = true
+		req.Cluster = name
+	case strings.HasPrefix(fullPath, "/api/v1/webhook-tokens"):
+		// Tokens may act on any cluster, managing them needs an unrestricted admin
+		req.Role = rbac.RoleAdmin
+		req.Cluster = "*"
+	case strings.HasPrefix(fullPath, "/api/v1/clusters") && method != http.MethodGet:
+		// Registering clusters needs an admin binding that isn't limited to some clusters
+		req.Role = rbac.RoleAdmin
+		req.Cluster = "*"
+	case method == http.MethodGet || method == http.MethodHead:
+		req.Role = rbac.RoleViewer
+		req.AnyNamespace = clusterInfoPaths[fullPath]
+	case namespace != "" && isOperationRoute(method, fullPath):
+		req.Role = rbac.RoleOperator
+	}
+	return req
+}
+
+// isOperationRoute reports whether the route is a day-2 operation on a single
+// namespaced object, like POST /deployments/:namespace/:name/restart, or a pod deletion
+func isOperationRoute(method, fullPath string) bool {
+	segments := strings.Split(strings.Trim(fullPath, "/"), "/")
+	if len(segments) < 2 {
+		return false
+	}
+	if method == http.MethodDelete {
+		return fullPath == "/api/v1/pods/:namespace/:name"
+	}
+	last, parent := segments[len(segments)-1], segments[len(segments)-2]
+	return method == http.MethodPost && parent == ":name" && !strings.HasPrefix(last, ":")
+}
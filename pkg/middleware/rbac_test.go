@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zxh326/kite/pkg/rbac"
+)
+
+func TestNewRBACRequest(t *testing.T) {
+	tests := []struct {
+		method    string
+		fullPath  string
+		namespace string
+		role      rbac.Role
+	}{
+		{http.MethodGet, "/api/v1/pods/:namespace/:name", "default", rbac.RoleViewer},
+		{http.MethodPost, "/api/v1/deployments/:namespace/:name/restart", "default", rbac.RoleOperator},
+		{http.MethodPost, "/api/v1/statefulsets/:namespace/:name/rollback", "default", rbac.RoleOperator},
+		{http.MethodDelete, "/api/v1/pods/:namespace/:name", "default", rbac.RoleOperator},
+		{http.MethodPut, "/api/v1/deployments/:namespace/:name", "default", rbac.RoleAdmin},
+		{http.MethodPost, "/api/v1/nodes/_all/:name/cordon", "", rbac.RoleAdmin},
+		{http.MethodGet, "/api/v1/terminal/:namespace/:podName/ws", "default", rbac.RoleOperator},
+		{http.MethodGet, "/api/v1/node-terminal/:nodeName/ws", "", rbac.RoleAdmin},
+		{http.MethodPost, "/api/v1/resources/apply", "", rbac.RoleAdmin},
+		{http.MethodGet, "/api/v1/audit", "", rbac.RoleAdmin},
+		{http.MethodGet, "/api/v1/recordings/:id/cast", "", rbac.RoleAdmin},
+		{http.MethodGet, "/api/v1/webhook-tokens", "", rbac.RoleAdmin},
+		{http.MethodGet, "/api/v1/clusters", "", rbac.RoleViewer},
+		{http.MethodDelete, "/api/v1/clusters/:name", "", rbac.RoleAdmin},
+		{http.MethodPost, "/api/v1/clusters/:name/health", "", rbac.RoleViewer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.fullPath, func(t *testing.T) {
+			assert.Equal(t, tt.role, newRBACRequest(tt.method, tt.fullPath, tt.namespace, "").Role)
+		})
+	}
+
+	req := newRBACRequest(http.MethodGet, "/api/v1/secrets/:namespace", "_all", "")
+	assert.Empty(t, req.Namespace)
+	assert.False(t, req.AnyNamespace)
+
+	req = newRBACRequest(http.MethodPost, "/api/v1/clusters", "", "")
+	assert.Equal(t, "*", req.Cluster)
+
+	req = newRBACRequest(http.MethodPost, "/api/v1/clusters/:name/health", "", "staging")
+	assert.Equal(t, "staging", req.Cluster, "the checked cluster is authorized")
+	req = newRBACRequest(http.MethodGet, "/api/v1/deployments/:namespace/:name", "default", "web")
+	assert.Empty(t, req.Cluster)
+}
+
+func TestNamespaceScopedViewer(t *testing.T) {
+	config, err := rbac.ParseConfig([]byte(`
+bindings:
+  - role: viewer
+    users: [alice]
+    namespaces: [team-a]
+`))
+	require.NoError(t, err)
+	alice := rbac.Subject{Username: "alice"}
+	allowed := func(method, fullPath, namespace string) bool {
+		req := newRBACRequest(method, fullPath, namespace, "")
+		req.Cluster = "prod"
+		return config.Allowed(alice, req)
+	}
+
+	assert.True(t, allowed(http.MethodGet, "/api/v1/pods/:namespace", "team-a"))
+	assert.False(t, allowed(http.MethodGet, "/api/v1/pods/:namespace", "team-b"))
+	assert.False(t, allowed(http.MethodGet, "/api/v1/secrets/:namespace", "_all"))
+	// Search filters its results per namespace, the overview only has counts
+	assert.True(t, allowed(http.MethodGet, "/api/v1/search", ""))
+	assert.True(t, allowed(http.MethodGet, "/api/v1/overview", ""))
+	assert.False(t, allowed(http.MethodPost, "/api/v1/deployments/:namespace/:name/restart", "team-a"))
+}
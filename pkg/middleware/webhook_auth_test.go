@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zxh326/kite/pkg/common"
+	"github.com/zxh326/kite/pkg/webhook"
+)
+
+func TestWebhookAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokenPath := filepath.Join(t.TempDir(), "tokens.json")
+	require.NoError(t, webhook.Init("file", tokenPath))
+	t.Cleanup(func() { _ = webhook.Init("memory", "") })
+
+	r := gin.New()
+	r.POST("/webhooks/events", WebhookAuth(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(gin.AuthUserKey)+" "+c.GetHeader(ClusterNameHeader))
+	})
+	body := `{"action":"restart","resource":"deployments","namespace":"apps","name":"web"}`
+	send := func(prepare func(req *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/events", strings.NewReader(body))
+		prepare(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	defaultCredentials := func(req *http.Request) {
+		req.SetBasicAuth(common.WebhookUsername, common.WebhookPassword)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send(func(*http.Request) {}).Code)
+	w := send(defaultCredentials)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "kite-webhook ", w.Body.String())
+
+	// Creating a token disables the default password
+	token, err := webhook.Create(&webhook.Token{Name: "ci", Cluster: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, send(defaultCredentials).Code)
+
+	w = send(func(req *http.Request) { req.SetBasicAuth("ci", token.Secret) })
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ci prod", w.Body.String(), "tokens limited to a cluster select it")
+	assert.Equal(t, http.StatusUnauthorized, send(func(req *http.Request) { req.SetBasicAuth("ci", "wrong") }).Code)
+
+	sign := func(secret string) func(req *http.Request) {
+		return func(req *http.Request) {
+			now := time.Now().Unix()
+			req.Header.Set(webhook.TokenHeader, "ci")
+			req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(now, 10))
+			req.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, now, []byte(body)))
+		}
+	}
+	w = send(sign(token.Secret))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ci prod", w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, send(sign("wrong")).Code)
+
+	// A token file that can't be read keeps the default password disabled
+	require.NoError(t, webhook.Revoke("ci"))
+	require.Equal(t, http.StatusOK, send(defaultCredentials).Code)
+	require.NoError(t, os.WriteFile(tokenPath, []byte("{corrupt"), 0o600))
+	assert.Equal(t, http.StatusUnauthorized, send(defaultCredentials).Code)
+
+	// The default password is never accepted with the memory store, the
+	// tokens that disable it would be lost on restart
+	require.NoError(t, webhook.Init("memory", ""))
+	assert.Equal(t, http.StatusUnauthorized, send(defaultCredentials).Code)
+
+	common.WebhookPassword, common.WebhookDefaultPassword = "s3cret", false
+	t.Cleanup(func() { common.WebhookPassword, common.WebhookDefaultPassword = "kite-webhook-password", true })
+	assert.Equal(t, http.StatusOK, send(defaultCredentials).Code, "a configured password is always accepted")
+}
@@ -0,0 +1,210 @@
+package rbac
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Role is a built-in Kite role, each role includes the permissions of the roles below it
+type Role string
+
+const (
+	// RoleViewer can read resources and logs
+	RoleViewer Role = "viewer"
+	// RoleOperator can also run day-2 operations (restart, scale, ...), delete pods and open pod terminals
+	RoleOperator Role = "operator"
+	// RoleAdmin can do everything, including editing resources and node terminals
+	RoleAdmin Role = "admin"
+)
+
+// WebhookGroup is the group of webhook callers, bind roles to it to scope webhooks
+const WebhookGroup = "kite:webhooks"
+
+func (r Role) level() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleOperator:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// Includes reports whether r grants at least the permissions of other
+func (r Role) Includes(other Role) bool {
+	return r.level() >= other.level()
+}
+
+// Binding grants a role to users and groups. Empty Clusters or Namespaces
+// means all of them, entries may use glob patterns like "prod-*".
+type Binding struct {
+	Role       Role     `json:"role"`
+	Users      []string `json:"users,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+	Clusters   []string `json:"clusters,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// Config is the content of the RBAC config file
+type Config struct {
+	Bindings []Binding `json:"bindings"`
+}
+
+// Subject is the identity a request is authorized for
+type Subject struct {
+	Username string
+	Groups   []string
+}
+
+// Request describes what a subject is trying to do
+type Request struct {
+	Cluster string
+	// Namespace is empty for cluster-wide requests
+	Namespace string
+	// AnyNamespace accepts bindings scoped to any namespace of the cluster
+	AnyNamespace bool
+	Role         Role
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == value {
+			return true
+		}
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Binding) matchesSubject(subject Subject) bool {
+	if matchAny(b.Users, subject.Username) {
+		return true
+	}
+	for _, group := range subject.Groups {
+		if matchAny(b.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Binding) matchesScope(req Request) bool {
+	if len(b.Clusters) > 0 && !matchAny(b.Clusters, req.Cluster) {
+		return false
+	}
+	if len(b.Namespaces) == 0 || req.AnyNamespace {
+		return true
+	}
+	// A namespaced binding never grants cluster-wide access
+	return req.Namespace != "" && matchAny(b.Namespaces, req.Namespace)
+}
+
+func (c *Config) validate() error {
+	for i, binding := range c.Bindings {
+		if binding.Role.level() == 0 {
+			return fmt.Errorf("binding %d: unknown role %q", i, binding.Role)
+		}
+		if len(binding.Users) == 0 && len(binding.Groups) == 0 {
+			return fmt.Errorf("binding %d: at least one user or group is required", i)
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether a binding grants the subject the requested role in the request scope
+func (c *Config) Allowed(subject Subject, req Request) bool {
+	for i := range c.Bindings {
+		binding := &c.Bindings[i]
+		if binding.Role.Includes(req.Role) && binding.matchesSubject(subject) && binding.matchesScope(req) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyBinding reports whether the subject is bound to any role at all
+func (c *Config) HasAnyBinding(subject Subject) bool {
+	return slices.ContainsFunc(c.Bindings, func(b Binding) bool {
+		return b.matchesSubject(subject)
+	})
+}
+
+// BindingsFor returns the bindings of a subject that apply to a cluster
+func (c *Config) BindingsFor(subject Subject, clusterName string) []Binding {
+	bindings := make([]Binding, 0)
+	for _, binding := range c.Bindings {
+		if binding.matchesSubject(subject) && (len(binding.Clusters) == 0 || matchAny(binding.Clusters, clusterName)) {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
+
+// ParseConfig parses a YAML or JSON RBAC config
+func ParseConfig(data []byte) (*Config, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// current is the loaded config, nil means role checks are disabled
+var current *Config
+
+// Load reads the RBAC config file and enables role checks
+func Load(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read RBAC config: %w", err)
+	}
+	config, err := ParseConfig(data)
+	if err != nil {
+		return fmt.Errorf("invalid RBAC config %s: %w", filename, err)
+	}
+	current = config
+	klog.Infof("Loaded %d role bindings from %s", len(config.Bindings), filename)
+	return nil
+}
+
+// Enabled reports whether a RBAC config is loaded
+func Enabled() bool {
+	return current != nil
+}
+
+// Current returns the loaded config, or nil when role checks are disabled
+func Current() *Config {
+	return current
+}
+
+// Authorize checks a request against the loaded config. Without a config
+// every authenticated user is allowed, like before roles existed.
+func Authorize(subject Subject, req Request) bool {
+	if current == nil {
+		return true
+	}
+	return current.Allowed(subject, req)
+}
+
+// SubjectFromUser builds a subject from the user stored in the gin context by RequireAuth
+func SubjectFromUser(user gin.H) Subject {
+	username, _ := user["username"].(string)
+	if username == "" {
+		username, _ = user["name"].(string)
+	}
+	groups, _ := user["groups"].([]string)
+	return Subject{Username: username, Groups: groups}
+}
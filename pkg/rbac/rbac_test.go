@@ -0,0 +1,62 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+bindings:
+  - role: admin
+    groups: ["platform"]
+  - role: operator
+    groups: ["oncall"]
+    clusters: ["prod-*"]
+    namespaces: ["payments", "orders"]
+  - role: viewer
+    users: ["*"]
+    clusters: ["staging"]
+`
+
+func TestConfigAllowed(t *testing.T) {
+	config, err := ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+
+	admin := Subject{Username: "alice", Groups: []string{"platform"}}
+	oncall := Subject{Username: "bob", Groups: []string{"oncall"}}
+	guest := Subject{Username: "carol"}
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		req      Request
+		expected bool
+	}{
+		{"admin can do anything", admin, Request{Cluster: "prod-eu", Role: RoleAdmin}, true},
+		{"oncall restarts in own namespace", oncall, Request{Cluster: "prod-eu", Namespace: "payments", Role: RoleOperator}, true},
+		{"oncall reads own namespace", oncall, Request{Cluster: "prod-eu", Namespace: "orders", Role: RoleViewer}, true},
+		{"oncall can't edit", oncall, Request{Cluster: "prod-eu", Namespace: "payments", Role: RoleAdmin}, false},
+		{"oncall can't operate other namespaces", oncall, Request{Cluster: "prod-eu", Namespace: "kube-system", Role: RoleOperator}, false},
+		{"oncall has no cluster-wide access", oncall, Request{Cluster: "prod-eu", Role: RoleViewer}, false},
+		{"oncall sees cluster info", oncall, Request{Cluster: "prod-eu", Role: RoleViewer, AnyNamespace: true}, true},
+		{"oncall has no access to other clusters", oncall, Request{Cluster: "staging", Namespace: "payments", Role: RoleOperator}, false},
+		{"everyone views staging", guest, Request{Cluster: "staging", Role: RoleViewer}, true},
+		{"nobody else views prod", guest, Request{Cluster: "prod-eu", Namespace: "payments", Role: RoleViewer}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, config.Allowed(tt.subject, tt.req))
+		})
+	}
+}
+
+func TestParseConfigValidation(t *testing.T) {
+	_, err := ParseConfig([]byte("bindings:\n  - role: superuser\n    users: [alice]\n"))
+	assert.Error(t, err)
+
+	_, err = ParseConfig([]byte("bindings:\n  - role: viewer\n"))
+	assert.Error(t, err)
+}
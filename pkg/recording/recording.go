@@ -0,0 +1,403 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"k8s.io/klog/v2"
+
+	"github.com/zxh326/kite/pkg/utils"
+)
+
+// Terminal kinds
+const (
+	KindPod   = "pod"
+	KindNode  = "node"
+	KindDebug = "debug"
+)
+
+// maxRecordingBytes stops recording sessions that produce unbounded output,
+// like a tail -f left open
+const maxRecordingBytes = 64 << 20
+
+// ErrNotFound is returned for unknown recording ids
+var ErrNotFound = errors.New("recording not found")
+
+// Recording describes a recorded terminal session, the session itself is an
+// asciicast v2 stream kept by the store
+type Recording struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	Cluster   string    `json:"cluster"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Pod       string    `json:"pod,omitempty"`
+	Container string    `json:"container,omitempty"`
+	Node      string    `json:"node,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+	// Size is the size of the cast in bytes, Truncated is set when the
+	// session went over the size limit or the store failed to write it, and
+	// its end wasn't recorded
+	Size      int64 `json:"size"`
+	Truncated bool  `json:"truncated,omitempty"`
+}
+
+// Target names what the terminal was opened on
+func (r *Recording) Target() string {
+	if r.Kind == KindNode {
+		return "node/" + r.Node
+	}
+	return fmt.Sprintf("pod/%s/%s/%s", r.Namespace, r.Pod, r.Container)
+}
+
+// Filter selects recordings in List, zero values match everything
+type Filter struct {
+	User    string
+	Cluster string
+	Kind    string
+	Since   time.Time
+	Limit   int
+}
+
+func (f *Filter) Match(r *Recording) bool {
+	switch {
+	case f.User != "" && r.User != f.User,
+		f.Cluster != "" && r.Cluster != f.Cluster,
+		f.Kind != "" && r.Kind != f.Kind,
+		!f.Since.IsZero() && r.StartedAt.Before(f.Since):
+		return false
+	}
+	return true
+}
+
+// Store persists recordings
+type Store interface {
+	// Create starts a recording, the cast is written to the returned writer
+	Create(rec *Recording) (io.WriteCloser, error)
+	// Update stores the final metadata of a recording
+	Update(rec *Recording) error
+	Get(id string) (*Recording, error)
+	// List returns matching recordings, newest first
+	List(filter Filter) ([]Recording, error)
+	// Open returns the asciicast of a recording
+	Open(id string) (io.ReadCloser, error)
+	Delete(id string) error
+	Close() error
+}
+
+// Retention bounds the recordings kept, zero values disable a bound
+type Retention struct {
+	MaxAge   time.Duration
+	MaxCount int
+}
+
+var (
+	mu        sync.RWMutex
+	store     Store = NewMemoryStore()
+	retention       = Retention{MaxAge: 30 * 24 * time.Hour, MaxCount: 1000}
+)
+
+// Init selects the store: file, memory or none to disable recording, and
+// applies the retention to existing recordings. Without a kind recordings
+// are kept in files when a path is given and in memory otherwise, since the
+// working directory may not be writable.
+func Init(kind, path string, r Retention) error {
+	var (
+		s   Store
+		err error
+	)
+	if kind == "" {
+		kind = "memory"
+		if path != "" {
+			kind = "file"
+		}
+	}
+	switch kind {
+	case "memory":
+		klog.Warningf("Terminal recordings are kept in memory, they are lost on restart and only the last %d are kept", maxMemoryRecordings)
+		s = NewMemoryStore()
+	case "file":
+		if path == "" {
+			path = "kite-recordings"
+		}
+		s, err = NewFileStore(path)
+	case "none":
+	default:
+		return fmt.Errorf("unknown recording store %q", kind)
+	}
+	if err != nil {
+		return err
+	}
+	initStore(s, r)
+	return nil
+}
+
+func initStore(s Store, r Retention) {
+	mu.Lock()
+	if store != nil {
+		_ = store.Close()
+	}
+	store = s
+	retention = r
+	mu.Unlock()
+	endInterrupted(s)
+	Prune()
+}
+
+// endInterrupted marks the recordings left running by a previous process as
+// ended and truncated, no session outlives a restart and retention only
+// applies to ended recordings
+func endInterrupted(s Store) {
+	if s == nil {
+		return
+	}
+	recordings, err := s.List(Filter{})
+	if err != nil {
+		klog.Errorf("Failed to list recordings: %v", err)
+		return
+	}
+	for _, rec := range recordings {
+		if !rec.EndedAt.IsZero() {
+			continue
+		}
+		rec.EndedAt = time.Now()
+		rec.Truncated = true
+		if err := s.Update(&rec); err != nil {
+			klog.Errorf("Failed to end interrupted recording %s: %v", rec.ID, err)
+		}
+	}
+}
+
+func current() Store {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store
+}
+
+// Enabled reports whether terminal sessions are recorded
+func Enabled() bool {
+	return current() != nil
+}
+
+// Get returns the metadata of a recording
+func Get(id string) (*Recording, error) {
+	s := current()
+	if s == nil {
+		return nil, ErrNotFound
+	}
+	return s.Get(id)
+}
+
+// List returns recordings of the configured store, newest first
+func List(filter Filter) ([]Recording, error) {
+	s := current()
+	if s == nil {
+		return []Recording{}, nil
+	}
+	return s.List(filter)
+}
+
+// Open returns the asciicast of a recording
+func Open(id string) (io.ReadCloser, error) {
+	s := current()
+	if s == nil {
+		return nil, ErrNotFound
+	}
+	return s.Open(id)
+}
+
+// Delete removes a recording
+func Delete(id string) error {
+	s := current()
+	if s == nil {
+		return ErrNotFound
+	}
+	return s.Delete(id)
+}
+
+// Close closes the configured store
+func Close() error {
+	if s := current(); s != nil {
+		return s.Close()
+	}
+	return nil
+}
+
+// Prune deletes recordings beyond the retention limits
+func Prune() {
+	s := current()
+	mu.RLock()
+	r := retention
+	mu.RUnlock()
+	if s == nil || (r.MaxAge <= 0 && r.MaxCount <= 0) {
+		return
+	}
+	recordings, err := s.List(Filter{})
+	if err != nil {
+		klog.Errorf("Failed to list recordings for retention: %v", err)
+		return
+	}
+	for i, rec := range recordings {
+		// Running sessions are kept, they are pruned once they end
+		if rec.EndedAt.IsZero() {
+			continue
+		}
+		expired := r.MaxAge > 0 && time.Since(rec.StartedAt) > r.MaxAge
+		if expired || (r.MaxCount > 0 && i >= r.MaxCount) {
+			if err := s.Delete(rec.ID); err != nil {
+				klog.Errorf("Failed to delete recording %s: %v", rec.ID, err)
+			}
+		}
+	}
+}
+
+// Recorder writes a terminal session as an asciicast v2 stream
+type Recorder struct {
+	mu        sync.Mutex
+	rec       *Recording
+	out       io.WriteCloser
+	store     Store
+	closed    bool
+	cols      uint16
+	rows      uint16
+	startedAt time.Time
+	pending   map[string][]byte
+}
+
+// Start begins recording a session, it returns nil when recording is
+// disabled. The caller fills in everything but ID and StartedAt.
+func Start(rec *Recording) (*Recorder, error) {
+	s := current()
+	if s == nil {
+		return nil, nil
+	}
+	rec.ID = utils.RandomString(16)
+	rec.StartedAt = time.Now()
+	out, err := s.Create(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{rec: rec, out: out, store: s, cols: 80, rows: 24, startedAt: rec.StartedAt, pending: map[string][]byte{}}
+	header, err := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     r.cols,
+		"height":    r.rows,
+		"timestamp": rec.StartedAt.Unix(),
+		"title":     fmt.Sprintf("%s@%s %s", rec.User, rec.Cluster, rec.Target()),
+		"env": map[string]string{
+			"TERM":         "xterm-256color",
+			"KITE_USER":    rec.User,
+			"KITE_CLUSTER": rec.Cluster,
+			"KITE_TARGET":  rec.Target(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.write(append(header, '\n')); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// ID returns the id of the recording
+func (r *Recorder) ID() string {
+	return r.rec.ID
+}
+
+func (r *Recorder) Input(data []byte) {
+	r.event("i", data)
+}
+
+func (r *Recorder) Output(data []byte) {
+	r.event("o", data)
+}
+
+func (r *Recorder) Resize(cols, rows uint16) {
+	r.event("r", fmt.Appendf(nil, "%dx%d", cols, rows))
+}
+
+func (r *Recorder) event(kind string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.rec.Truncated {
+		return
+	}
+	// Streams are chunked without regard to characters, a multi-byte
+	// character split between chunks is written with the next event
+	if kind != "r" {
+		data = append(r.pending[kind], data...)
+		var rest []byte
+		data, rest = splitIncompleteRune(data)
+		r.pending[kind] = rest
+		if len(data) == 0 {
+			return
+		}
+	}
+	line, err := json.Marshal([]any{time.Since(r.startedAt).Seconds(), kind, string(data)})
+	if err != nil {
+		return
+	}
+	if r.rec.Size+int64(len(line)) > maxRecordingBytes {
+		r.rec.Truncated = true
+		return
+	}
+	if err := r.writeLocked(append(line, '\n')); err != nil {
+		klog.Errorf("Failed to write recording %s, stopping it: %v", r.rec.ID, err)
+		r.rec.Truncated = true
+	}
+}
+
+func (r *Recorder) write(data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.writeLocked(data)
+}
+
+func (r *Recorder) writeLocked(data []byte) error {
+	n, err := r.out.Write(data)
+	r.rec.Size += int64(n)
+	return err
+}
+
+// Close finishes the recording and applies the retention limits
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.rec.EndedAt = time.Now()
+	err := r.out.Close()
+	rec := *r.rec
+	r.mu.Unlock()
+
+	if updateErr := r.store.Update(&rec); err == nil {
+		err = updateErr
+	}
+	go Prune()
+	return err
+}
+
+// splitIncompleteRune splits off a trailing partial UTF-8 sequence
+func splitIncompleteRune(data []byte) ([]byte, []byte) {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i], bytes.Clone(data[i:])
+			}
+			break
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,164 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readCast(t *testing.T, s Store, id string) (map[string]any, [][]any) {
+	cast, err := s.Open(id)
+	require.NoError(t, err)
+	defer func() {
+		_ = cast.Close()
+	}()
+
+	scanner := bufio.NewScanner(cast)
+	require.True(t, scanner.Scan())
+	var header map[string]any
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+	var events [][]any
+	for scanner.Scan() {
+		var event []any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return header, events
+}
+
+func testStore(t *testing.T, s Store) {
+	initStore(s, Retention{})
+
+	recorder, err := Start(&Recording{User: "alice", Cluster: "prod", Kind: KindPod, Namespace: "default", Pod: "web-1", Container: "app"})
+	require.NoError(t, err)
+	recorder.Resize(120, 40)
+	recorder.Input([]byte("ls\r"))
+	// "é" split across two writes is recorded whole
+	recorder.Output([]byte("caf\xc3"))
+	recorder.Output([]byte("\xa9\r\n"))
+	require.NoError(t, recorder.Close())
+
+	header, events := readCast(t, s, recorder.ID())
+	assert.Equal(t, float64(2), header["version"])
+	assert.Equal(t, "alice@prod pod/default/web-1/app", header["title"])
+	assert.Equal(t, "prod", header["env"].(map[string]any)["KITE_CLUSTER"])
+
+	var kinds, data []string
+	for _, event := range events {
+		kinds = append(kinds, event[1].(string))
+		data = append(data, event[2].(string))
+	}
+	assert.Equal(t, []string{"r", "i", "o", "o"}, kinds)
+	assert.Equal(t, []string{"120x40", "ls\r", "caf", "é\r\n"}, data)
+
+	rec, err := s.Get(recorder.ID())
+	require.NoError(t, err)
+	assert.False(t, rec.EndedAt.IsZero())
+	assert.Positive(t, rec.Size)
+
+	_, err = Start(&Recording{User: "bob", Cluster: "staging", Kind: KindNode, Node: "node-1"})
+	require.NoError(t, err)
+	all, err := List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "bob", all[0].User)
+	prod, err := List(Filter{Cluster: "prod"})
+	require.NoError(t, err)
+	assert.Len(t, prod, 1)
+
+	require.NoError(t, Delete(recorder.ID()))
+	_, err = Get(recorder.ID())
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Open("../etc/passwd")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	testStore(t, s)
+}
+
+func TestPrune(t *testing.T) {
+	s := NewMemoryStore()
+	initStore(s, Retention{MaxAge: time.Hour, MaxCount: 2})
+	for i, age := range []time.Duration{2 * time.Hour, 3 * time.Minute, 2 * time.Minute, time.Minute} {
+		w, err := s.Create(&Recording{ID: string(rune('a' + i)), StartedAt: time.Now().Add(-age), EndedAt: time.Now()})
+		require.NoError(t, err)
+		_, _ = io.WriteString(w, "{}\n")
+	}
+	Prune()
+
+	kept, err := List(Filter{})
+	require.NoError(t, err)
+	var ids []string
+	for _, rec := range kept {
+		ids = append(ids, rec.ID)
+	}
+	assert.Equal(t, []string{"d", "c"}, ids)
+}
+
+func TestMemoryStoreKeepsRunningSessions(t *testing.T) {
+	s := NewMemoryStore()
+	running, err := s.Create(&Recording{ID: "running", StartedAt: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	for i := 0; i < maxMemoryRecordings; i++ {
+		w, err := s.Create(&Recording{ID: fmt.Sprintf("ended%d", i), StartedAt: time.Now(), EndedAt: time.Now()})
+		require.NoError(t, err)
+		_, err = io.WriteString(w, "{}\n")
+		require.NoError(t, err)
+	}
+	_, err = s.Get("running")
+	require.NoError(t, err, "running sessions are never evicted")
+	_, err = s.Get("ended0")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// The byte bound evicts ended recordings, then refuses the write
+	_, err = running.Write(make([]byte, maxMemoryBytes-2))
+	require.NoError(t, err)
+	recordings, err := s.List(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, recordings, 1)
+	_, err = running.Write(make([]byte, 3))
+	assert.ErrorIs(t, err, errMemoryStoreFull)
+}
+
+func TestInitDefaultStore(t *testing.T) {
+	t.Cleanup(func() { _ = Init("memory", "", Retention{}) })
+
+	require.NoError(t, Init("", "", Retention{}))
+	assert.IsType(t, &MemoryStore{}, current(), "nothing is written without a path")
+	require.NoError(t, Init("", t.TempDir(), Retention{}))
+	assert.IsType(t, &FileStore{}, current())
+}
+
+func TestInitEndsInterruptedRecordings(t *testing.T) {
+	t.Cleanup(func() { _ = Init("memory", "", Retention{}) })
+	dir := t.TempDir()
+	s, err := NewFileStore(dir)
+	require.NoError(t, err)
+	// Left running by a process that crashed
+	for id, age := range map[string]time.Duration{"old": 48 * time.Hour, "recent": time.Minute} {
+		w, err := s.Create(&Recording{ID: id, StartedAt: time.Now().Add(-age)})
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+
+	require.NoError(t, Init("file", dir, Retention{MaxAge: 24 * time.Hour}))
+	_, err = Get("old")
+	assert.ErrorIs(t, err, ErrNotFound, "interrupted recordings are subject to retention")
+	rec, err := Get("recent")
+	require.NoError(t, err)
+	assert.False(t, rec.EndedAt.IsZero())
+	assert.True(t, rec.Truncated)
+}
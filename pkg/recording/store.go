@@ -0,0 +1,301 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxMemoryRecordings and maxMemoryBytes bound the recordings kept by the
+// memory store regardless of the retention settings
+const (
+	maxMemoryRecordings = 100
+	maxMemoryBytes      = 256 << 20
+)
+
+// errMemoryStoreFull is returned for writes that don't fit in the memory
+// store once every ended recording has been evicted
+var errMemoryStoreFull = errors.New("memory recording store is full")
+
+// MemoryStore keeps recordings in memory, they are lost on restart
+type MemoryStore struct {
+	mu         sync.RWMutex
+	recordings map[string]*memoryRecording
+	size       int64
+}
+
+type memoryRecording struct {
+	meta Recording
+	data bytes.Buffer
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{recordings: map[string]*memoryRecording{}}
+}
+
+// memoryWriter appends to a recording under the store lock since the cast
+// may be read while the session is still running
+type memoryWriter struct {
+	store *MemoryStore
+	rec   *memoryRecording
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	if _, ok := w.store.recordings[w.rec.meta.ID]; !ok {
+		return 0, ErrNotFound
+	}
+	if !w.store.evictLocked(0, int64(len(p))) {
+		return 0, errMemoryStoreFull
+	}
+	n, err := w.rec.data.Write(p)
+	w.store.size += int64(n)
+	return n, err
+}
+
+func (w *memoryWriter) Close() error {
+	return nil
+}
+
+func (s *MemoryStore) Create(rec *Recording) (io.WriteCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Running sessions are never evicted, so the count may go over the
+	// bound while they are, their writes are held to the byte bound
+	s.evictLocked(1, 0)
+	r := &memoryRecording{meta: *rec}
+	s.recordings[rec.ID] = r
+	return &memoryWriter{store: s, rec: r}, nil
+}
+
+// evictLocked drops the oldest ended recordings until another count
+// recordings and size bytes fit, it reports whether they do
+func (s *MemoryStore) evictLocked(count int, size int64) bool {
+	for len(s.recordings)+count > maxMemoryRecordings || s.size+size > maxMemoryBytes {
+		var oldest *memoryRecording
+		for _, r := range s.recordings {
+			if r.meta.EndedAt.IsZero() {
+				continue
+			}
+			if oldest == nil || r.meta.StartedAt.Before(oldest.meta.StartedAt) {
+				oldest = r
+			}
+		}
+		if oldest == nil {
+			return false
+		}
+		s.deleteLocked(oldest)
+	}
+	return true
+}
+
+func (s *MemoryStore) deleteLocked(r *memoryRecording) {
+	delete(s.recordings, r.meta.ID)
+	s.size -= int64(r.data.Len())
+}
+
+func (s *MemoryStore) Update(rec *Recording) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.recordings[rec.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	r.meta = *rec
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (*Recording, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.recordings[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	meta := r.meta
+	return &meta, nil
+}
+
+func (s *MemoryStore) List(filter Filter) ([]Recording, error) {
+	s.mu.RLock()
+	recordings := make([]Recording, 0, len(s.recordings))
+	for _, r := range s.recordings {
+		recordings = append(recordings, r.meta)
+	}
+	s.mu.RUnlock()
+	return filterRecordings(recordings, filter), nil
+}
+
+func (s *MemoryStore) Open(id string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.recordings[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(bytes.Clone(r.data.Bytes()))), nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.recordings[id]
+	if !ok {
+		return ErrNotFound
+	}
+	s.deleteLocked(r)
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// FileStore keeps each recording as <id>.cast with its metadata in
+// <id>.json in a directory
+type FileStore struct {
+	dir string
+}
+
+// recordingIDPattern guards file paths built from ids given by clients
+var recordingIDPattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(id, ext string) (string, error) {
+	if !recordingIDPattern.MatchString(id) {
+		return "", ErrNotFound
+	}
+	return filepath.Join(s.dir, id+ext), nil
+}
+
+func (s *FileStore) Create(rec *Recording) (io.WriteCloser, error) {
+	if err := s.Update(rec); err != nil {
+		return nil, err
+	}
+	path, err := s.path(rec.ID, ".cast")
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+}
+
+func (s *FileStore) Update(rec *Recording) error {
+	path, err := s.path(rec.ID, ".json")
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// Written aside and renamed so List never reads a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *FileStore) Get(id string) (*Recording, error) {
+	path, err := s.path(id, ".json")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// List reads the metadata of every recording, it is meant for modest
+// numbers of recordings which the retention keeps it to
+func (s *FileStore) List(filter Filter) ([]Recording, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	recordings := make([]Recording, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		rec, err := s.Get(id)
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, *rec)
+	}
+	return filterRecordings(recordings, filter), nil
+}
+
+func (s *FileStore) Open(id string) (io.ReadCloser, error) {
+	path, err := s.path(id, ".cast")
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *FileStore) Delete(id string) error {
+	meta, err := s.path(id, ".json")
+	if err != nil {
+		return err
+	}
+	cast, _ := s.path(id, ".cast")
+	if err := os.Remove(meta); errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if err := os.Remove(cast); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// filterRecordings sorts newest first and applies the filter and limit
+func filterRecordings(recordings []Recording, filter Filter) []Recording {
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	result := make([]Recording, 0, len(recordings))
+	for i := range recordings {
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+		if filter.Match(&recordings[i]) {
+			result = append(result, recordings[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,180 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Registry notification formats
+const (
+	RegistryDockerHub = "dockerhub"
+	RegistryHarbor    = "harbor"
+	// RegistryOCI is the notification format of the CNCF distribution
+	// registry, also sent by Zot, GitLab and most self-hosted registries
+	RegistryOCI = "oci"
+)
+
+const dockerHubRegistry = "docker.io"
+
+// ImageRef is a normalized image reference, images of Docker Hub are in
+// docker.io and official images in its library namespace
+type ImageRef struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// ParseImageRef normalizes an image reference like nginx, ghcr.io/org/app:v1
+// or registry:5000/app@sha256:...
+func ParseImageRef(image string) ImageRef {
+	var ref ImageRef
+	image, ref.Digest, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, ref.Tag = image[:i], image[i+1:]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	ref.Registry, ref.Repository = dockerHubRegistry, image
+	if domain, rest, ok := strings.Cut(image, "/"); ok &&
+		(strings.ContainsAny(domain, ".:") || domain == "localhost") {
+		ref.Registry, ref.Repository = domain, rest
+	}
+	if ref.Registry == "index.docker.io" || ref.Registry == "registry-1.docker.io" {
+		ref.Registry = dockerHubRegistry
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	return ref
+}
+
+func (r ImageRef) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Matches reports whether an image runs the repository and tag of the
+// reference, digests are ignored
+func (r ImageRef) Matches(image string) bool {
+	other := ParseImageRef(image)
+	return r.Tag != "" && other.Registry == r.Registry && other.Repository == r.Repository && other.Tag == r.Tag
+}
+
+// WithDigest pins an image to a digest, keeping how it is written
+func WithDigest(image, digest string) string {
+	image, _, _ = strings.Cut(image, "@")
+	return image + "@" + digest
+}
+
+type dockerHubPush struct {
+	PushData struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+	Repository struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+}
+
+type harborEvent struct {
+	Type      string `json:"type"`
+	EventData struct {
+		Resources []struct {
+			Digest      string `json:"digest"`
+			Tag         string `json:"tag"`
+			ResourceURL string `json:"resource_url"`
+		} `json:"resources"`
+	} `json:"event_data"`
+}
+
+type distributionEnvelope struct {
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+			Digest     string `json:"digest"`
+			URL        string `json:"url"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+}
+
+// ParseRegistryPush returns the tagged images pushed according to a registry
+// notification. Other events, like pulls and deletions, yield no images.
+// registry overrides the registry of the images when pods pull them through
+// another name than the one the registry knows itself by.
+func ParseRegistryPush(format string, body []byte, registry string) ([]ImageRef, error) {
+	var refs []ImageRef
+	switch format {
+	case RegistryDockerHub:
+		var push dockerHubPush
+		if err := json.Unmarshal(body, &push); err != nil {
+			return nil, fmt.Errorf("invalid Docker Hub payload: %w", err)
+		}
+		if push.Repository.RepoName == "" || push.PushData.Tag == "" {
+			return nil, fmt.Errorf("invalid Docker Hub payload: missing repository or tag")
+		}
+		refs = append(refs, ParseImageRef(push.Repository.RepoName+":"+push.PushData.Tag))
+	case RegistryHarbor:
+		var event harborEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return nil, fmt.Errorf("invalid Harbor payload: %w", err)
+		}
+		if event.Type != "PUSH_ARTIFACT" && event.Type != "pushImage" {
+			return nil, nil
+		}
+		for _, resource := range event.EventData.Resources {
+			if resource.Tag == "" || resource.ResourceURL == "" {
+				continue
+			}
+			ref := ParseImageRef(resource.ResourceURL)
+			ref.Tag, ref.Digest = resource.Tag, resource.Digest
+			refs = append(refs, ref)
+		}
+	case RegistryOCI:
+		var envelope distributionEnvelope
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return nil, fmt.Errorf("invalid OCI registry payload: %w", err)
+		}
+		for _, event := range envelope.Events {
+			// Blob pushes and pushes by digest only carry no tag
+			if event.Action != "push" || event.Target.Tag == "" {
+				continue
+			}
+			image := event.Target.Repository + ":" + event.Target.Tag
+			host := event.Request.Host
+			if host == "" {
+				if u, err := url.Parse(event.Target.URL); err == nil {
+					host = u.Host
+				}
+			}
+			if host != "" {
+				image = host + "/" + image
+			}
+			ref := ParseImageRef(image)
+			ref.Digest = event.Target.Digest
+			refs = append(refs, ref)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported registry format %q, expected %s, %s or %s", format, RegistryDockerHub, RegistryHarbor, RegistryOCI)
+	}
+	if registry != "" {
+		for i := range refs {
+			refs[i].Registry = registry
+		}
+	}
+	return refs, nil
+}
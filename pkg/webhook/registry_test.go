@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected ImageRef
+	}{
+		{"nginx", ImageRef{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"index.docker.io/team/app:v1", ImageRef{Registry: "docker.io", Repository: "team/app", Tag: "v1"}},
+		{"registry.local:5000/team/app:v2", ImageRef{Registry: "registry.local:5000", Repository: "team/app", Tag: "v2"}},
+		{"localhost/app@sha256:abc", ImageRef{Registry: "localhost", Repository: "app", Digest: "sha256:abc"}},
+		{"ghcr.io/org/app:v1@sha256:abc", ImageRef{Registry: "ghcr.io", Repository: "org/app", Tag: "v1", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseImageRef(tt.image))
+		})
+	}
+
+	pushed := ParseImageRef("docker.io/library/nginx:1.27")
+	assert.True(t, pushed.Matches("nginx:1.27"))
+	assert.True(t, pushed.Matches("nginx:1.27@sha256:old"))
+	assert.False(t, pushed.Matches("nginx:1.26"))
+	assert.False(t, pushed.Matches("ghcr.io/library/nginx:1.27"))
+
+	assert.Equal(t, "nginx:1.27@sha256:new", WithDigest("nginx:1.27@sha256:old", "sha256:new"))
+}
+
+func TestParseRegistryPush(t *testing.T) {
+	dockerHub := `{"push_data":{"tag":"v3","pusher":"ci"},"repository":{"repo_name":"team/app","namespace":"team","name":"app"}}`
+	refs, err := ParseRegistryPush(RegistryDockerHub, []byte(dockerHub), "")
+	require.NoError(t, err)
+	assert.Equal(t, []ImageRef{{Registry: "docker.io", Repository: "team/app", Tag: "v3"}}, refs)
+
+	harbor := `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"digest":"sha256:abc","tag":"v1","resource_url":"harbor.local/library/app:v1"}],"repository":{"repo_full_name":"library/app"}}}`
+	refs, err = ParseRegistryPush(RegistryHarbor, []byte(harbor), "")
+	require.NoError(t, err)
+	assert.Equal(t, []ImageRef{{Registry: "harbor.local", Repository: "library/app", Tag: "v1", Digest: "sha256:abc"}}, refs)
+
+	refs, err = ParseRegistryPush(RegistryHarbor, []byte(`{"type":"PULL_ARTIFACT"}`), "")
+	require.NoError(t, err)
+	assert.Empty(t, refs)
+
+	oci := `{"events":[
+		{"action":"push","target":{"mediaType":"application/vnd.oci.image.layer.v1.tar","repository":"team/app","digest":"sha256:layer"},"request":{"host":"registry.local:5000"}},
+		{"action":"push","target":{"repository":"team/app","tag":"v2","digest":"sha256:def"},"request":{"host":"registry.local:5000"}},
+		{"action":"pull","target":{"repository":"team/app","tag":"v1"},"request":{"host":"registry.local:5000"}}
+	]}`
+	refs, err = ParseRegistryPush(RegistryOCI, []byte(oci), "")
+	require.NoError(t, err)
+	assert.Equal(t, []ImageRef{{Registry: "registry.local:5000", Repository: "team/app", Tag: "v2", Digest: "sha256:def"}}, refs)
+
+	// Pods may pull through another name than the registry's own
+	refs, err = ParseRegistryPush(RegistryOCI, []byte(oci), "registry.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com", refs[0].Registry)
+
+	_, err = ParseRegistryPush("quay", []byte(`{}`), "")
+	assert.Error(t, err)
+}
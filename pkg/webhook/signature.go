@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers of signed webhook requests
+const (
+	TokenHeader     = "X-Kite-Token"
+	TimestampHeader = "X-Kite-Timestamp"
+	SignatureHeader = "X-Kite-Signature"
+)
+
+// SignatureTolerance is how far the timestamp of a signed request may be
+// from the server time, signatures seen within it are rejected as replays
+const SignatureTolerance = 5 * time.Minute
+
+// ErrInvalidSignature is returned for signed requests that fail verification
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header value of a request body: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" with the token secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature authenticates a signed request of a token
+func VerifySignature(name, timestamp, signature string, body []byte) (*Token, error) {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid " + TimestampHeader + " header, expected unix seconds")
+	}
+	now := time.Now()
+	if skew := now.Sub(time.Unix(ts, 0)).Abs(); skew > SignatureTolerance {
+		return nil, errors.New("request timestamp is outside the allowed window")
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		return nil, ErrInvalidSignature
+	}
+
+	t, err := current().Get(name)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	for _, secret := range t.secrets(now) {
+		if hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+			if !replays.remember(name+" "+signature, time.Unix(ts, 0).Add(SignatureTolerance)) {
+				return nil, errors.New("request was already received")
+			}
+			touch(t)
+			return t, nil
+		}
+	}
+	return nil, ErrInvalidSignature
+}
+
+// replayCache remembers verified signatures until their timestamp leaves the
+// tolerance window, after which the timestamp check rejects them
+type replayCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+var replays = &replayCache{seen: map[string]time.Time{}}
+
+// remember records a signature, it returns false if it was already seen
+func (r *replayCache) remember(key string, expires time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for k, exp := range r.seen {
+		if now.After(exp) {
+			delete(r.seen, k)
+		}
+	}
+	if _, ok := r.seen[key]; ok {
+		return false
+	}
+	r.seen[key] = expires
+	return true
+}
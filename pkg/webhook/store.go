@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Store persists webhook tokens with their secrets
+type Store interface {
+	// List returns all tokens sorted by name
+	List() ([]Token, error)
+	Get(name string) (*Token, error)
+	// Save creates or replaces a token
+	Save(t *Token) error
+	Delete(name string) error
+}
+
+// MemoryStore keeps tokens in memory, they are lost on restart
+type MemoryStore struct {
+	mu     sync.RWMutex
+	tokens map[string]Token
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: map[string]Token{}}
+}
+
+func (s *MemoryStore) List() ([]Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedTokens(s.tokens), nil
+}
+
+func (s *MemoryStore) Get(name string) (*Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tokens[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &t, nil
+}
+
+func (s *MemoryStore) Save(t *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[t.Name] = *t
+	return nil
+}
+
+func (s *MemoryStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.tokens, name)
+	return nil
+}
+
+// FileStore keeps tokens in a JSON file readable only by Kite
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) List() ([]Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return sortedTokens(tokens), nil
+}
+
+func (s *FileStore) Get(name string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := tokens[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &t, nil
+}
+
+func (s *FileStore) Save(t *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	tokens[t.Name] = *t
+	return s.write(tokens)
+}
+
+func (s *FileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[name]; !ok {
+		return ErrNotFound
+	}
+	delete(tokens, name)
+	return s.write(tokens)
+}
+
+func (s *FileStore) read() (map[string]Token, error) {
+	tokens := map[string]Token{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// write replaces the file atomically so a crash never leaves it half written
+func (s *FileStore) write(tokens map[string]Token) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".kite-webhook-tokens-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func sortedTokens(tokens map[string]Token) []Token {
+	result := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
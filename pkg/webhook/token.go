@@ -0,0 +1,282 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// ErrNotFound is returned for unknown token names
+	ErrNotFound = errors.New("webhook token not found")
+	// ErrExists is returned when creating a token whose name is taken
+	ErrExists = errors.New("webhook token already exists")
+	// ErrInvalidName is returned for token names that can't be used
+	ErrInvalidName = errors.New("invalid webhook token name")
+)
+
+// tokenNamePattern keeps names usable as basic auth usernames and file keys
+var tokenNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]{0,61}[a-z0-9])?$`)
+
+// lastUsedInterval limits how often the last use of a token is saved
+const lastUsedInterval = time.Minute
+
+// Token is a named webhook credential, usually one per pipeline. Empty scope
+// fields allow everything.
+type Token struct {
+	Name string `json:"name"`
+	// Cluster the token acts on, it is also the default cluster of requests
+	// that don't select one
+	Cluster    string   `json:"cluster,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Resources are the resource kinds the token may act on, like deployments
+	Resources []string `json:"resources,omitempty"`
+	Actions   []string `json:"actions,omitempty"`
+
+	Secret string `json:"secret,omitempty"`
+	// PreviousSecret is still accepted until PreviousExpiresAt after a
+	// rotation with a grace period
+	PreviousSecret    string    `json:"previousSecret,omitempty"`
+	PreviousExpiresAt time.Time `json:"previousExpiresAt,omitzero"`
+
+	CreatedAt  time.Time `json:"createdAt"`
+	RotatedAt  time.Time `json:"rotatedAt,omitzero"`
+	LastUsedAt time.Time `json:"lastUsedAt,omitzero"`
+}
+
+// Redacted returns a copy of the token without its secrets
+func (t Token) Redacted() Token {
+	t.Secret = ""
+	t.PreviousSecret = ""
+	t.Namespaces = slices.Clone(t.Namespaces)
+	t.Resources = slices.Clone(t.Resources)
+	t.Actions = slices.Clone(t.Actions)
+	return t
+}
+
+// Allows reports why the token may not act on the target, nil when it may
+func (t *Token) Allows(cluster, namespace, resource, action string) error {
+	switch {
+	case t.Cluster != "" && t.Cluster != cluster:
+		return fmt.Errorf("webhook token %s is limited to cluster %s", t.Name, t.Cluster)
+	case len(t.Namespaces) > 0 && !slices.Contains(t.Namespaces, namespace):
+		return fmt.Errorf("webhook token %s may not act in namespace %s", t.Name, namespace)
+	case len(t.Resources) > 0 && !slices.Contains(t.Resources, resource):
+		return fmt.Errorf("webhook token %s may not act on %s", t.Name, resource)
+	case len(t.Actions) > 0 && !slices.Contains(t.Actions, action):
+		return fmt.Errorf("webhook token %s may not %s", t.Name, action)
+	}
+	return nil
+}
+
+// secrets returns the secrets currently accepted for the token
+func (t *Token) secrets(now time.Time) []string {
+	secrets := []string{t.Secret}
+	if t.PreviousSecret != "" && now.Before(t.PreviousExpiresAt) {
+		secrets = append(secrets, t.PreviousSecret)
+	}
+	return secrets
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var (
+	mu    sync.RWMutex
+	store Store = NewMemoryStore()
+)
+
+// Init selects the token store: file or memory. The file store needs a
+// path, without a kind tokens are kept in a file when a path is given and
+// in memory otherwise.
+func Init(kind, path string) error {
+	if kind == "" {
+		kind = "memory"
+		if path != "" {
+			kind = "file"
+		}
+	}
+	var s Store
+	switch kind {
+	case "memory":
+		s = NewMemoryStore()
+	case "file":
+		if path == "" {
+			return errors.New("the file webhook token store requires a path")
+		}
+		s = NewFileStore(path)
+	default:
+		return fmt.Errorf("unknown webhook token store %q", kind)
+	}
+	mu.Lock()
+	store = s
+	mu.Unlock()
+	return nil
+}
+
+func current() Store {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store
+}
+
+// List returns the tokens without their secrets, sorted by name
+func List() ([]Token, error) {
+	tokens, err := current().List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range tokens {
+		tokens[i] = tokens[i].Redacted()
+	}
+	return tokens, nil
+}
+
+// Get returns a token without its secrets
+func Get(name string) (*Token, error) {
+	t, err := current().Get(name)
+	if err != nil {
+		return nil, err
+	}
+	redacted := t.Redacted()
+	return &redacted, nil
+}
+
+// Persistent reports whether tokens outlive a restart
+func Persistent() bool {
+	_, memory := current().(*MemoryStore)
+	return !memory
+}
+
+// Exists reports whether any token is configured. A store that can't be
+// read counts as having tokens, so the default password stays disabled.
+func Exists() bool {
+	tokens, err := current().List()
+	if err != nil {
+		klog.Errorf("Failed to read webhook tokens: %v", err)
+		return true
+	}
+	return len(tokens) > 0
+}
+
+// Create stores a new token with a generated secret, the returned token is
+// the only place the secret is shown
+func Create(t *Token) (*Token, error) {
+	if !tokenNamePattern.MatchString(t.Name) {
+		return nil, fmt.Errorf("%w %q, use lowercase letters, digits, '-' and '.'", ErrInvalidName, t.Name)
+	}
+	s := current()
+	if _, err := s.Get(t.Name); err == nil {
+		return nil, ErrExists
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	created := *t
+	created.Secret = secret
+	created.PreviousSecret = ""
+	created.PreviousExpiresAt = time.Time{}
+	created.CreatedAt = time.Now()
+	created.RotatedAt = time.Time{}
+	created.LastUsedAt = time.Time{}
+	if err := s.Save(&created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Rotate replaces the secret of a token. The old secret keeps working for
+// the grace period so pipelines can be updated without failed deliveries.
+func Rotate(name string, grace time.Duration) (*Token, error) {
+	s := current()
+	t, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	t.PreviousSecret, t.PreviousExpiresAt = "", time.Time{}
+	if grace > 0 {
+		t.PreviousSecret, t.PreviousExpiresAt = t.Secret, now.Add(grace)
+	}
+	t.Secret = secret
+	t.RotatedAt = now
+	if err := s.Save(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Revoke deletes a token, requests using it fail right away
+func Revoke(name string) error {
+	return current().Delete(name)
+}
+
+// CheckPassword authenticates basic auth credentials of a token
+func CheckPassword(name, password string) (*Token, bool) {
+	t, err := current().Get(name)
+	if err != nil {
+		return nil, false
+	}
+	for _, secret := range t.secrets(time.Now()) {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(password)) == 1 {
+			touch(t)
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// touch records the use of a token, at most once per lastUsedInterval
+func touch(t *Token) {
+	now := time.Now()
+	if now.Sub(t.LastUsedAt) < lastUsedInterval {
+		return
+	}
+	t.LastUsedAt = now
+	// Saving the fetched copy could undo a concurrent rotation, re-read it
+	s := current()
+	if latest, err := s.Get(t.Name); err == nil && latest.Secret == t.Secret {
+		latest.LastUsedAt = now
+		_ = s.Save(latest)
+	}
+}
+
+// contextKey stores the token authenticating a webhook request
+const contextKey = "webhook-token"
+
+// FromContext returns the token of the current request, requests using the
+// global webhook credentials have none
+func FromContext(c *gin.Context) (*Token, bool) {
+	v, ok := c.Get(contextKey)
+	if !ok {
+		return nil, false
+	}
+	t, ok := v.(*Token)
+	return t, ok
+}
+
+// NewContext attaches the token authenticating the request
+func NewContext(c *gin.Context, t *Token) {
+	c.Set(contextKey, t)
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenLifecycle(t *testing.T) {
+	require.NoError(t, Init("file", filepath.Join(t.TempDir(), "tokens.json")))
+	t.Cleanup(func() { _ = Init("memory", "") })
+
+	_, err := Create(&Token{Name: "Bad Name"})
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	token, err := Create(&Token{Name: "ci-web", Cluster: "prod", Namespaces: []string{"apps"}})
+	require.NoError(t, err)
+	assert.Len(t, token.Secret, 64)
+	_, err = Create(&Token{Name: "ci-web"})
+	assert.ErrorIs(t, err, ErrExists)
+
+	tokens, err := List()
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Empty(t, tokens[0].Secret)
+
+	_, ok := CheckPassword("ci-web", token.Secret)
+	assert.True(t, ok)
+	_, ok = CheckPassword("ci-web", "wrong")
+	assert.False(t, ok)
+
+	// The old secret keeps working during the grace period only
+	rotated, err := Rotate("ci-web", time.Hour)
+	require.NoError(t, err)
+	assert.NotEqual(t, token.Secret, rotated.Secret)
+	_, ok = CheckPassword("ci-web", token.Secret)
+	assert.True(t, ok)
+	_, err = Rotate("ci-web", 0)
+	require.NoError(t, err)
+	_, ok = CheckPassword("ci-web", token.Secret)
+	assert.False(t, ok)
+
+	require.NoError(t, Revoke("ci-web"))
+	assert.ErrorIs(t, Revoke("ci-web"), ErrNotFound)
+	assert.False(t, Exists())
+}
+
+func TestInitStore(t *testing.T) {
+	t.Cleanup(func() { _ = Init("memory", "") })
+
+	assert.Error(t, Init("file", ""), "the file store needs a path")
+	require.NoError(t, Init("", ""))
+	assert.False(t, Persistent())
+	require.NoError(t, Init("", filepath.Join(t.TempDir(), "tokens.json")))
+	assert.True(t, Persistent())
+}
+
+func TestVerifySignature(t *testing.T) {
+	require.NoError(t, Init("memory", ""))
+	token, err := Create(&Token{Name: "signed"})
+	require.NoError(t, err)
+
+	body := []byte(`{"action":"restart","resource":"deployments","namespace":"apps","name":"web"}`)
+	now := time.Now().Unix()
+	signature := Sign(token.Secret, now, body)
+
+	_, err = VerifySignature("signed", strconv.FormatInt(now, 10), signature, body)
+	require.NoError(t, err)
+
+	_, err = VerifySignature("signed", strconv.FormatInt(now, 10), signature, body)
+	assert.Error(t, err, "replayed request")
+
+	_, err = VerifySignature("signed", strconv.FormatInt(now, 10), Sign(token.Secret, now, []byte("{}")), body)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	old := time.Now().Add(-SignatureTolerance - time.Minute).Unix()
+	_, err = VerifySignature("signed", strconv.FormatInt(old, 10), Sign(token.Secret, old, body), body)
+	assert.Error(t, err)
+
+	_, err = VerifySignature("unknown", strconv.FormatInt(now, 10), signature, body)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestTokenAllows(t *testing.T) {
+	token := &Token{
+		Name:       "ci",
+		Cluster:    "prod",
+		Namespaces: []string{"apps"},
+		Resources:  []string{"deployments"},
+		Actions:    []string{"updateImage"},
+	}
+	assert.NoError(t, token.Allows("prod", "apps", "deployments", "updateImage"))
+	assert.Error(t, token.Allows("staging", "apps", "deployments", "updateImage"))
+	assert.Error(t, token.Allows("prod", "kube-system", "deployments", "updateImage"))
+	assert.Error(t, token.Allows("prod", "apps", "daemonsets", "updateImage"))
+	assert.Error(t, token.Allows("prod", "apps", "deployments", "restart"))
+	assert.NoError(t, (&Token{Name: "any"}).Allows("prod", "apps", "deployments", "restart"))
+}
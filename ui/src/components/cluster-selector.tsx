@@ -96,6 +96,27 @@ export function ClusterSelector() {
                 {cluster.isDefault && (
                   <Badge className="text-xs">Default</Badge>
                 )}
+                {cluster.health?.status === 'syncing' && (
+                  <Badge variant="outline" className="text-xs">
+                    Syncing
+                  </Badge>
+                )}
+                {(cluster.health?.status === 'unreachable' ||
+                  cluster.health?.status === 'degraded') && (
+                  <Badge
+                    variant={
+                      cluster.health.status === 'unreachable'
+                        ? 'destructive'
+                        : 'secondary'
+                    }
+                    className="text-xs"
+                    title={cluster.health.apiServer.error}
+                  >
+                    {cluster.health.status === 'unreachable'
+                      ? 'Unreachable'
+                      : 'Degraded'}
+                  </Badge>
+                )}
               </div>
               <span className="text-xs text-muted-foreground">
                 {cluster.version}
@@ -61,7 +61,9 @@ export function CreateResourceDialog({
     try {
       const result = await applyResource(yaml)
       toast.success(
-        `Resource ${result.kind}/${result.name} created successfully`
+        result.results.length > 1
+          ? result.message
+          : `Resource ${result.kind}/${result.name} applied successfully`
       )
       setYaml('')
       onOpenChange(false)
@@ -200,9 +200,13 @@ export function GlobalSearch({ open, onOpenChange }: GlobalSearchProps) {
                   }
                   const Icon = config.icon
                   const isFav = isFavorite(result.id)
+                  // Custom resources are named <plural>.<group>
+                  const base = result.resourceType.includes('.')
+                    ? `/crds/${result.resourceType}`
+                    : `/${result.resourceType}`
                   const path = result.namespace
-                    ? `/${result.resourceType}/${result.namespace}/${result.name}`
-                    : `/${result.resourceType}/${result.name}`
+                    ? `${base}/${result.namespace}/${result.name}`
+                    : `${base}/${result.name}`
                   return (
                     <CommandItem
                       key={result.id}
@@ -224,6 +228,11 @@ export function GlobalSearch({ open, onOpenChange }: GlobalSearchProps) {
                             {t('common.namespace')}: {result.namespace}
                           </div>
                         )}
+                        {result.match && (
+                          <div className="text-xs text-muted-foreground mt-1 truncate">
+                            {result.match}
+                          </div>
+                        )}
                       </div>
                       <button
                         onClick={(e) => {
@@ -0,0 +1,172 @@
+import { useCallback, useEffect, useRef, useState } from 'react'
+import {
+  IconPlayerPause,
+  IconPlayerPlay,
+  IconPlayerSkipBack,
+} from '@tabler/icons-react'
+import { Terminal as XTerm } from '@xterm/xterm'
+
+import '@xterm/xterm/css/xterm.css'
+
+import { fetchRecordingCast, TerminalRecording } from '@/lib/api'
+import { Button } from '@/components/ui/button'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+
+type CastEvent = [number, 'o' | 'i' | 'r', string]
+
+const SPEEDS = [1, 2, 4, 8]
+// Pauses longer than this are shortened during replay
+const MAX_IDLE_SECONDS = 2
+
+function parseCast(cast: string) {
+  const [headerLine, ...lines] = cast.split('\n').filter(Boolean)
+  const header = JSON.parse(headerLine) as { width: number; height: number }
+  const events = lines.map((line) => JSON.parse(line) as CastEvent)
+  return { header, events }
+}
+
+export function RecordingPlayer({
+  recording,
+}: {
+  recording: TerminalRecording
+}) {
+  const containerRef = useRef<HTMLDivElement>(null)
+  const terminalRef = useRef<XTerm | null>(null)
+  const eventsRef = useRef<CastEvent[]>([])
+  const positionRef = useRef(0)
+  const timerRef = useRef<ReturnType<typeof setTimeout> | null>(null)
+  const [playing, setPlaying] = useState(false)
+  const [speed, setSpeed] = useState(1)
+  const [progress, setProgress] = useState(0)
+  const [error, setError] = useState<string | null>(null)
+
+  useEffect(() => {
+    let disposed = false
+    const terminal = new XTerm({
+      fontFamily: '"Maple Mono", Monaco, Menlo, "Ubuntu Mono", monospace',
+      fontSize: 13,
+      disableStdin: true,
+    })
+    terminalRef.current = terminal
+    if (containerRef.current) {
+      terminal.open(containerRef.current)
+    }
+
+    fetchRecordingCast(recording.id)
+      .then((cast) => {
+        if (disposed) return
+        const { header, events } = parseCast(cast)
+        terminal.resize(header.width, header.height)
+        eventsRef.current = events
+        positionRef.current = 0
+        setProgress(0)
+      })
+      .catch((err: Error) => setError(err.message))
+
+    return () => {
+      disposed = true
+      if (timerRef.current) clearTimeout(timerRef.current)
+      terminal.dispose()
+    }
+  }, [recording.id])
+
+  const step = useCallback(() => {
+    const events = eventsRef.current
+    const terminal = terminalRef.current
+    const index = positionRef.current
+    if (!terminal || index >= events.length) {
+      setPlaying(false)
+      return
+    }
+    const [time, kind, data] = events[index]
+    if (kind === 'o') {
+      terminal.write(data)
+    } else if (kind === 'r') {
+      const [cols, rows] = data.split('x').map(Number)
+      if (cols > 0 && rows > 0) terminal.resize(cols, rows)
+    }
+    positionRef.current = index + 1
+    setProgress(Math.round(((index + 1) / events.length) * 100))
+
+    const next = events[index + 1]
+    if (next) {
+      const delay = Math.min(next[0] - time, MAX_IDLE_SECONDS) / speed
+      timerRef.current = setTimeout(step, delay * 1000)
+    } else {
+      setPlaying(false)
+    }
+  }, [speed])
+
+  useEffect(() => {
+    if (playing) {
+      step()
+    }
+    return () => {
+      if (timerRef.current) clearTimeout(timerRef.current)
+    }
+  }, [playing, step])
+
+  const restart = () => {
+    if (timerRef.current) clearTimeout(timerRef.current)
+    terminalRef.current?.reset()
+    positionRef.current = 0
+    setProgress(0)
+    setPlaying(true)
+  }
+
+  const target =
+    recording.kind === 'node'
+      ? `node/${recording.node}`
+      : `${recording.namespace}/${recording.pod}/${recording.container}`
+
+  return (
+    <Card>
+      <CardHeader className="flex flex-row items-center justify-between space-y-0">
+        <CardTitle className="text-sm font-medium">
+          {recording.user} · {target} ·{' '}
+          {new Date(recording.startedAt).toLocaleString()}
+        </CardTitle>
+        <div className="flex items-center gap-2">
+          <Button variant="ghost" size="sm" onClick={restart}>
+            <IconPlayerSkipBack className="h-4 w-4" />
+          </Button>
+          <Button
+            variant="ghost"
+            size="sm"
+            onClick={() => setPlaying((p) => !p)}
+          >
+            {playing ? (
+              <IconPlayerPause className="h-4 w-4" />
+            ) : (
+              <IconPlayerPlay className="h-4 w-4" />
+            )}
+          </Button>
+          <Button
+            variant="outline"
+            size="sm"
+            onClick={() =>
+              setSpeed(SPEEDS[(SPEEDS.indexOf(speed) + 1) % SPEEDS.length])
+            }
+          >
+            {speed}x
+          </Button>
+          <span className="text-muted-foreground w-10 text-right text-xs">
+            {progress}%
+          </span>
+        </div>
+      </CardHeader>
+      <CardContent>
+        {error ? (
+          <div className="text-destructive text-sm">{error}</div>
+        ) : (
+          <div ref={containerRef} className="overflow-auto rounded bg-black" />
+        )}
+        {recording.truncated && (
+          <div className="text-muted-foreground mt-2 text-xs">
+            The session exceeded the size limit, its end was not recorded.
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
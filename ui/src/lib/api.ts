@@ -87,11 +87,19 @@ export interface SearchResult {
   namespace?: string
   resourceType: string
   createdAt: string
+  match?: string
+  cluster?: string
+}
+
+export interface ClusterError {
+  cluster: string
+  error: string
 }
 
 export interface SearchResponse {
   results: SearchResult[]
   total: number
+  errors?: ClusterError[]
 }
 
 // Global search API
@@ -100,6 +108,7 @@ export const globalSearch = async (
   options?: {
     limit?: number
     namespace?: string
+    allClusters?: boolean
   }
 ): Promise<SearchResponse> => {
   if (query.length < 2) {
@@ -115,7 +124,8 @@ export const globalSearch = async (
     params.append('namespace', options.namespace)
   }
 
-  const endpoint = `/search?${params.toString()}`
+  const path = options?.allClusters ? '/multicluster/search' : '/search'
+  const endpoint = `${path}?${params.toString()}`
   return fetchAPI<SearchResponse>(endpoint)
 }
 
@@ -237,6 +247,66 @@ export const restartUnitedDeployment = async (
   return restartKruiseWorkload('uniteddeployments', namespace, name)
 }
 
+// OpenKruise canary release APIs, for CloneSets and Advanced StatefulSets
+export type KruiseCanaryResource = 'clonesets' | 'advancedstatefulsets'
+
+export interface KruiseRevisionStatus {
+  replicas: number
+  readyReplicas: number
+  updatedReplicas: number
+  updatedReadyReplicas: number
+  currentRevision?: string
+  updateRevision?: string
+  partition: number
+  paused: boolean
+}
+
+export const pauseKruiseWorkload = async (
+  resource: KruiseCanaryResource,
+  namespace: string,
+  name: string
+) => {
+  return apiClient.post<{ message: string; revisions: KruiseRevisionStatus }>(
+    `/${resource}/${namespace}/${name}/pause`,
+    {}
+  )
+}
+
+export const resumeKruiseWorkload = async (
+  resource: KruiseCanaryResource,
+  namespace: string,
+  name: string
+) => {
+  return apiClient.post<{ message: string; revisions: KruiseRevisionStatus }>(
+    `/${resource}/${namespace}/${name}/resume`,
+    {}
+  )
+}
+
+// Set either the partition, or release the given number of pods to the
+// update revision
+export const setKruisePartition = async (
+  resource: KruiseCanaryResource,
+  namespace: string,
+  name: string,
+  target: { partition: number } | { release: number }
+) => {
+  return apiClient.post<{ message: string; revisions: KruiseRevisionStatus }>(
+    `/${resource}/${namespace}/${name}/partition`,
+    target
+  )
+}
+
+export const getKruiseRevisions = async (
+  resource: KruiseCanaryResource,
+  namespace: string,
+  name: string
+) => {
+  return apiClient.get<{ message: string; revisions: KruiseRevisionStatus }>(
+    `/${resource}/${namespace}/${name}/revisions`
+  )
+}
+
 
 // Node operation APIs
 export const drainNode = async (
@@ -371,6 +441,21 @@ export const deleteResource = async <T extends ResourceType>(
 // Apply resource from YAML
 export interface ApplyResourceRequest {
   yaml: string
+  namespace?: string
+  dryRun?: boolean
+  force?: boolean
+}
+
+export interface ApplyResourceResult {
+  apiVersion: string
+  kind: string
+  name: string
+  namespace?: string
+  success: boolean
+  action?: 'created' | 'configured' | 'unchanged'
+  error?: string
+  diff?: string
+  object?: Record<string, unknown>
 }
 
 export interface ApplyResourceResponse {
@@ -378,13 +463,17 @@ export interface ApplyResourceResponse {
   kind: string
   name: string
   namespace?: string
+  dryRun: boolean
+  results: ApplyResourceResult[]
 }
 
 export const applyResource = async (
-  yaml: string
+  yaml: string,
+  options: Omit<ApplyResourceRequest, 'yaml'> = {}
 ): Promise<ApplyResourceResponse> => {
   return await apiClient.post<ApplyResourceResponse>('/resources/apply', {
     yaml,
+    ...options,
   })
 }
 
@@ -844,8 +933,45 @@ export const useSimplePagination = <T extends ResourceType>(
 }
 
 // Logs API functions
+export interface LogEntry {
+  line: string
+  timestamp?: string
+  level?: 'trace' | 'debug' | 'info' | 'warn' | 'error' | 'fatal'
+  message: string
+  fields?: Record<string, unknown>
+  highlights?: [number, number][]
+}
+
+export interface LogFilterOptions {
+  include?: string
+  exclude?: string
+  match?: string
+  level?: LogEntry['level']
+  format?: 'text' | 'json'
+}
+
+const appendLogFilterParams = (
+  params: URLSearchParams,
+  filter?: LogFilterOptions
+) => {
+  if (!filter) return
+  for (const key of [
+    'include',
+    'exclude',
+    'match',
+    'level',
+    'format',
+  ] as const) {
+    const value = filter[key]
+    if (value) {
+      params.append(key, value)
+    }
+  }
+}
+
 export interface LogsResponse {
   logs: string[]
+  entries?: LogEntry[]
   container?: string
   pod: string
   namespace: string
@@ -861,7 +987,7 @@ export const fetchPodLogs = (
     timestamps?: boolean
     previous?: boolean
     sinceSeconds?: number
-  }
+  } & LogFilterOptions
 ): Promise<LogsResponse> => {
   const params = new URLSearchParams()
   params.append('follow', 'false') // Explicitly set follow=false for static logs
@@ -881,11 +1007,93 @@ export const fetchPodLogs = (
   if (options?.sinceSeconds !== undefined) {
     params.append('sinceSeconds', options.sinceSeconds.toString())
   }
+  appendLogFilterParams(params, options)
 
   const endpoint = `/logs/${namespace}/${podName}${params.toString() ? `?${params.toString()}` : ''}`
   return fetchAPI<LogsResponse>(endpoint)
 }
 
+export interface WorkloadLogsResponse {
+  logs: string[]
+  pods: string[]
+  errors: Record<string, string>
+  truncated: boolean
+  namespace: string
+}
+
+// Function to fetch the interleaved logs of all pods of a workload
+export const fetchWorkloadLogs = (
+  resource: 'deployments' | 'statefulsets' | 'daemonsets' | 'clonesets',
+  namespace: string,
+  name: string,
+  options?: {
+    container?: string
+    tailLines?: number
+    timestamps?: boolean
+    sinceSeconds?: number
+  }
+): Promise<WorkloadLogsResponse> => {
+  const params = new URLSearchParams()
+  params.append('follow', 'false')
+
+  if (options?.container) {
+    params.append('container', options.container)
+  }
+  if (options?.tailLines !== undefined) {
+    params.append('tailLines', options.tailLines.toString())
+  }
+  if (options?.timestamps !== undefined) {
+    params.append('timestamps', options.timestamps.toString())
+  }
+  if (options?.sinceSeconds !== undefined) {
+    params.append('sinceSeconds', options.sinceSeconds.toString())
+  }
+
+  return fetchAPI<WorkloadLogsResponse>(
+    `/workload-logs/${resource}/${namespace}/${name}?${params.toString()}`
+  )
+}
+
+// URLs to download logs as files, used as link targets so the browser
+// handles the download
+export const getPodLogsDownloadUrl = (
+  namespace: string,
+  podName: string,
+  options?: { container?: string; previous?: boolean; timestamps?: boolean }
+): string => {
+  const params = new URLSearchParams()
+  if (options?.container) {
+    params.append('container', options.container)
+  }
+  if (options?.previous) {
+    params.append('previous', 'true')
+  }
+  if (options?.timestamps) {
+    params.append('timestamps', 'true')
+  }
+  const currentCluster = localStorage.getItem('current-cluster')
+  if (currentCluster) {
+    params.append('x-cluster-name', currentCluster)
+  }
+  return `${API_BASE_URL}/logs/${namespace}/${podName}/download?${params.toString()}`
+}
+
+export const getLogsBundleUrl = (
+  namespace: string,
+  name: string,
+  resource?: 'deployments' | 'statefulsets' | 'daemonsets' | 'clonesets'
+): string => {
+  const params = new URLSearchParams()
+  const currentCluster = localStorage.getItem('current-cluster')
+  if (currentCluster) {
+    params.append('x-cluster-name', currentCluster)
+  }
+  const path = resource
+    ? `/workload-logs/${resource}/${namespace}/${name}/bundle`
+    : `/logs/${namespace}/${name}/bundle`
+  return `${API_BASE_URL}${path}?${params.toString()}`
+}
+
 // Function to create SSE-based logs connection (follow=true)
 export const createLogsSSEStream = (
   namespace: string,
@@ -896,7 +1104,7 @@ export const createLogsSSEStream = (
     timestamps?: boolean
     previous?: boolean
     sinceSeconds?: number
-  },
+  } & LogFilterOptions,
   onMessage?: (data: string) => void,
   onError?: (error: Error) => void,
   onClose?: () => void,
@@ -920,6 +1128,7 @@ export const createLogsSSEStream = (
   if (options?.sinceSeconds !== undefined) {
     params.append('sinceSeconds', options.sinceSeconds.toString())
   }
+  appendLogFilterParams(params, options)
 
   const currentCluster = localStorage.getItem('current-cluster')
   if (currentCluster) {
@@ -1359,3 +1568,171 @@ export function useRelatedResources(
     placeholderData: (prev) => prev,
   })
 }
+
+// Terminal session recordings
+export interface TerminalRecording {
+  id: string
+  user: string
+  cluster: string
+  kind: 'pod' | 'node' | 'debug'
+  namespace?: string
+  pod?: string
+  container?: string
+  node?: string
+  startedAt: string
+  endedAt: string
+  size: number
+  truncated?: boolean
+}
+
+export function useRecordings(options?: {
+  user?: string
+  kind?: TerminalRecording['kind']
+  limit?: number
+}) {
+  return useQuery({
+    queryKey: ['recordings', options],
+    queryFn: () => {
+      const params = new URLSearchParams()
+      if (options?.user) params.append('user', options.user)
+      if (options?.kind) params.append('kind', options.kind)
+      if (options?.limit) params.append('limit', options.limit.toString())
+      return fetchAPI<{ items: TerminalRecording[]; enabled: boolean }>(
+        `/recordings?${params.toString()}`
+      )
+    },
+  })
+}
+
+// Returns the asciicast v2 text of a recording
+export const fetchRecordingCast = (id: string): Promise<string> =>
+  fetchAPI<string>(`/recordings/${id}/cast`)
+
+export const deleteRecording = async (id: string) => {
+  await apiClient.delete(`/recordings/${id}`)
+}
+
+export interface WebhookToken {
+  name: string
+  cluster?: string
+  namespaces?: string[]
+  resources?: string[]
+  actions?: string[]
+  // Only returned when the token is created or rotated
+  secret?: string
+  previousExpiresAt?: string
+  createdAt: string
+  rotatedAt?: string
+  lastUsedAt?: string
+}
+
+export function useWebhookTokens() {
+  return useQuery({
+    queryKey: ['webhook-tokens'],
+    queryFn: () => fetchAPI<{ items: WebhookToken[] }>('/webhook-tokens'),
+  })
+}
+
+export const createWebhookToken = async (
+  token: Pick<
+    WebhookToken,
+    'name' | 'cluster' | 'namespaces' | 'resources' | 'actions'
+  >
+): Promise<WebhookToken> => {
+  return apiClient.post<WebhookToken>('/webhook-tokens', token)
+}
+
+export const rotateWebhookToken = async (
+  name: string,
+  gracePeriodSeconds = 0
+): Promise<WebhookToken> => {
+  return apiClient.post<WebhookToken>(`/webhook-tokens/${name}/rotate`, {
+    gracePeriodSeconds,
+  })
+}
+
+export const revokeWebhookToken = async (name: string) => {
+  await apiClient.delete(`/webhook-tokens/${name}`)
+}
+
+export type HistoryResource = 'deployments' | 'statefulsets' | 'daemonsets'
+
+export interface WorkloadRevision {
+  revision: number
+  name: string
+  changeCause?: string
+  images: string[]
+  createdAt: string
+  current: boolean
+}
+
+export interface RolloutStatus {
+  status: 'progressing' | 'stalled' | 'completed' | 'paused'
+  reason?: string
+  message?: string
+  replicas: number
+  updatedReplicas: number
+  readyReplicas: number
+  availableReplicas: number
+}
+
+export function useRolloutHistory(
+  resource: HistoryResource,
+  namespace: string,
+  name: string
+) {
+  return useQuery({
+    queryKey: ['rollout-history', resource, namespace, name],
+    queryFn: () =>
+      fetchAPI<{ revisions: WorkloadRevision[] }>(
+        `/${resource}/${namespace}/${name}/history`
+      ),
+  })
+}
+
+// to defaults to the current revision
+export const fetchRevisionDiff = (
+  resource: HistoryResource,
+  namespace: string,
+  name: string,
+  from: number,
+  to?: number
+) => {
+  const params = new URLSearchParams({ from: from.toString() })
+  if (to) params.append('to', to.toString())
+  return fetchAPI<{
+    from: WorkloadRevision
+    to: WorkloadRevision
+    diff: string
+  }>(`/${resource}/${namespace}/${name}/history/diff?${params.toString()}`)
+}
+
+// Revision 0 rolls back to the previous revision
+export const rollbackWorkload = async (
+  resource: HistoryResource,
+  namespace: string,
+  name: string,
+  revision = 0
+) => {
+  return apiClient.post<{
+    message: string
+    revision: WorkloadRevision
+    changed: boolean
+  }>(`/${resource}/${namespace}/${name}/rollback`, { revision })
+}
+
+export function useRolloutStatus(
+  resource: HistoryResource,
+  namespace: string,
+  name: string,
+  options?: { refreshInterval?: number }
+) {
+  return useQuery({
+    queryKey: ['rollout-status', resource, namespace, name],
+    queryFn: () =>
+      fetchAPI<RolloutStatus>(
+        `/${resource}/${namespace}/${name}/rollout-status`
+      ),
+    refetchInterval: options?.refreshInterval,
+  })
+}
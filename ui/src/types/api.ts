@@ -40,6 +40,24 @@ export interface Cluster {
   name: string
   version: string
   isDefault: boolean
+  registered: boolean
+  health?: ClusterHealth
+}
+
+export interface ComponentHealth {
+  healthy: boolean
+  lastChecked: string
+  lastSeen?: string
+  error?: string
+}
+
+export interface ClusterHealth {
+  status: 'unknown' | 'healthy' | 'syncing' | 'degraded' | 'unreachable'
+  apiServer: ComponentHealth
+  cacheState: 'disabled' | 'idle' | 'syncing' | 'synced'
+  cache?: ComponentHealth
+  prometheus?: ComponentHealth
+  metricsServer?: ComponentHealth
 }
 
 export interface CustomResource {